@@ -0,0 +1,153 @@
+package cliutil
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// ArgRest is the value of the arg tag key for options that collect all
+// remaining positional arguments.
+const ArgRest = "rest"
+
+// ErrInvalidArgs is returned when the arg tag keys of a struct don't describe
+// a valid list of positional arguments.
+var ErrInvalidArgs = errors.New("invalid positional arguments")
+
+// argSpec describes a positional argument defined by the arg tag key.
+type argSpec struct {
+	name     string
+	pos      int
+	rest     bool
+	required bool
+	complete CompletionFunc
+}
+
+// newArgSpec returns the argSpec for an option's tag. False is returned if
+// the option isn't a positional argument.
+func newArgSpec(tag map[string]string) (spec argSpec, ok bool, err error) {
+	s, ok := tag["arg"]
+	if !ok {
+		return
+	}
+
+	spec = argSpec{name: tag["option"], required: tagBool(tag, "required")}
+	if s == ArgRest {
+		spec.rest = true
+	} else if spec.pos, err = strconv.Atoi(s); err != nil || spec.pos < 0 {
+		err = fmt.Errorf("%w: arg must be a non-negative integer or %q, got %q", ErrInvalidArgs, ArgRest, s)
+	}
+	return
+}
+
+// usage returns the argument formatted for cobra.Command.Use.
+func (spec argSpec) usage() string {
+	s := strings.ToUpper(spec.name)
+	if spec.rest {
+		s += "..."
+	}
+	if !spec.required {
+		s = "[" + s + "]"
+	}
+	return s
+}
+
+// setArgs sets the command's Use and Args so that positional arguments are
+// validated and stored in the Flagger's config, where ReadOptions reads them
+// from with the same type conversions as flags.
+func (f *Flagger) setArgs(specs []argSpec) error {
+	if len(specs) == 0 {
+		return nil
+	}
+
+	// Sort the positional arguments, leaving the rest argument last.
+	sort.SliceStable(specs, func(i, j int) bool {
+		if specs[i].rest != specs[j].rest {
+			return specs[j].rest
+		}
+		return specs[i].pos < specs[j].pos
+	})
+
+	var (
+		min, max int
+		rest     *argSpec
+		optional string
+	)
+	use := []string{f.cmd.Name()}
+
+	for idx := range specs {
+		spec := specs[idx]
+		switch {
+		case spec.rest && rest != nil:
+			return fmt.Errorf("%w: %s and %s both collect the remaining args", ErrInvalidArgs, rest.name, spec.name)
+		case spec.rest:
+			rest = &specs[idx]
+		case spec.pos != idx:
+			return fmt.Errorf("%w: %s has position %d, expected %d", ErrInvalidArgs, spec.name, spec.pos, idx)
+		default:
+			max++
+		}
+
+		if spec.required && optional != "" {
+			return fmt.Errorf("%w: required arg %s follows optional arg %s", ErrInvalidArgs, spec.name, optional)
+		}
+		if spec.required {
+			min++
+		} else if optional == "" {
+			optional = spec.name
+		}
+
+		use = append(use, spec.usage())
+	}
+
+	f.cmd.Use = strings.Join(use, " ")
+	f.cmd.Args = func(cmd *cobra.Command, args []string) error {
+		if len(args) < min {
+			return NewUsageError(fmt.Errorf("requires at least %d arg(s), received %d", min, len(args)))
+		}
+		if len(args) > max && rest == nil {
+			return NewUsageError(fmt.Errorf("accepts at most %d arg(s), received %d", max, len(args)))
+		}
+
+		// Arguments that weren't passed are reset to nil, which viper treats
+		// as unset, so values from a previous execution don't carry over.
+		var set []string
+		for idx, spec := range specs[:max] {
+			if idx < len(args) {
+				f.cfg.Set(spec.name, args[idx])
+				set = append(set, spec.name)
+			} else {
+				f.cfg.Set(spec.name, nil)
+			}
+		}
+		if rest != nil && len(args) > max {
+			f.cfg.Set(rest.name, args[max:])
+			set = append(set, rest.name)
+		} else if rest != nil {
+			f.cfg.Set(rest.name, nil)
+		}
+
+		// Record the arguments that were passed for OptionValues.
+		cfgmetaMu.Lock()
+		defer cfgmetaMu.Unlock()
+		meta := getConfigMeta(f.cfg)
+		meta.args = make(map[string]bool, len(set))
+		for _, name := range set {
+			meta.args[name] = true
+		}
+		return nil
+	}
+
+	for _, spec := range specs {
+		if spec.complete != nil {
+			f.cmd.ValidArgsFunction = argsCompletionFunc(specs)
+			break
+		}
+	}
+
+	return nil
+}
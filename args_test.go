@@ -0,0 +1,136 @@
+package cliutil_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cpliakas/cliutil"
+	"github.com/go-test/deep"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type ArgsInput struct {
+	Source string   `cliutil:"option=source arg=0 required"`
+	Count  int      `cliutil:"option=count arg=1 default=1"`
+	Files  []string `cliutil:"option=files arg=rest"`
+	Force  bool     `cliutil:"option=force"`
+}
+
+func TestSetOptionsArgsUse(t *testing.T) {
+	cmd := newTestCommand(t, viper.New(), &ArgsInput{})
+	if ex := "test SOURCE [COUNT] [FILES...]"; cmd.Use != ex {
+		t.Errorf("got %q, expected %q", cmd.Use, ex)
+	}
+}
+
+func TestReadOptionsArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		ex   ArgsInput
+	}{
+		{[]string{"src"}, ArgsInput{Source: "src", Count: 1, Files: []string{}}},
+		{[]string{"src", "3", "--force"}, ArgsInput{Source: "src", Count: 3, Files: []string{}, Force: true}},
+		{[]string{"src", "3", "a", "b"}, ArgsInput{Source: "src", Count: 3, Files: []string{"a", "b"}}},
+	}
+
+	for _, tt := range tests {
+		input := &ArgsInput{}
+		cmd := newTestCommand(t, viper.New(), input)
+		cmd.SetArgs(tt.args)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("%v: unexpected error: %v", tt.args, err)
+		}
+		if diff := deep.Equal(*input, tt.ex); diff != nil {
+			t.Errorf("%v: %v", tt.args, diff)
+		}
+	}
+}
+
+func TestReadOptionsArgsMissing(t *testing.T) {
+	cmd := newTestCommand(t, viper.New(), &ArgsInput{})
+	cmd.SetArgs([]string{})
+
+	err := cmd.Execute()
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if code := cliutil.ExitCode(err); code != cliutil.ExitCodeUsage {
+		t.Errorf("got exit code %d, expected %d", code, cliutil.ExitCodeUsage)
+	}
+}
+
+type InvalidArgsInput struct {
+	First  string `cliutil:"option=first arg=0"`
+	Second string `cliutil:"option=second arg=2"`
+}
+
+type OptionalArgsInput struct {
+	First  string `cliutil:"option=first arg=0"`
+	Second string `cliutil:"option=second arg=1 required"`
+}
+
+func TestSetOptionsArgsInvalid(t *testing.T) {
+	for _, input := range []interface{}{&InvalidArgsInput{}, &OptionalArgsInput{}} {
+		cmd := &cobra.Command{Use: "test"}
+		err := cliutil.NewFlagger(cmd, viper.New()).SetOptions(input)
+		if !errors.Is(err, cliutil.ErrInvalidArgs) {
+			t.Errorf("%T: got %v, expected %v", input, err, cliutil.ErrInvalidArgs)
+		}
+	}
+}
+
+type RestArgsInput struct {
+	Ports []int `cliutil:"option=ports arg=rest"`
+}
+
+type RestKeyValueInput struct {
+	Labels map[string]string `cliutil:"option=labels arg=rest"`
+}
+
+func TestReadOptionsArgsRest(t *testing.T) {
+	ports := &RestArgsInput{}
+	labels := &RestKeyValueInput{}
+
+	for _, input := range []interface{}{ports, labels} {
+		cmd := newTestCommand(t, viper.New(), input)
+
+		if input == ports {
+			cmd.SetArgs([]string{"80", "8080:8082"})
+		} else {
+			cmd.SetArgs([]string{"env=prod", "owner=web team"})
+		}
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("%T: unexpected error: %v", input, err)
+		}
+	}
+
+	if diff := deep.Equal(ports.Ports, []int{80, 8080, 8081, 8082}); diff != nil {
+		t.Error(diff)
+	}
+	ex := map[string]string{"env": "prod", "owner": "web team"}
+	if diff := deep.Equal(labels.Labels, ex); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestReadOptionsArgsReexecute(t *testing.T) {
+	input := &ArgsInput{}
+	cmd := newTestCommand(t, viper.New(), input)
+
+	cmd.SetArgs([]string{"src", "3", "a", "b"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	*input = ArgsInput{}
+	cmd.SetArgs([]string{"other"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	ex := ArgsInput{Source: "other", Count: 1, Files: []string{}}
+	if diff := deep.Equal(*input, ex); diff != nil {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,145 @@
+package cliutil
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+// ErrCompleterNotRegistered is returned when an option's complete key names a
+// completer that isn't registered.
+var ErrCompleterNotRegistered = errors.New("completer not registered")
+
+// CompletionFunc is a definition for functions that return shell completions
+// for the value of an option. It is compatible with
+// cobra.Command.RegisterFlagCompletionFunc.
+type CompletionFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+var (
+	completersMu sync.RWMutex
+	completers   = make(map[string]CompletionFunc)
+)
+
+// RegisterCompleter registers a CompletionFunc by name so that options can
+// use it via complete=<name>, e.g., to complete values fetched from an API.
+func RegisterCompleter(name string, fn CompletionFunc) {
+	completersMu.Lock()
+	defer completersMu.Unlock()
+	completers[name] = fn
+}
+
+// CompleteFiles returns a CompletionFunc that completes file names, limited
+// to the given extensions if any are passed.
+func CompleteFiles(exts ...string) CompletionFunc {
+	return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		if len(exts) == 0 {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+		return exts, cobra.ShellCompDirectiveFilterFileExt
+	}
+}
+
+// CompleteDirs returns a CompletionFunc that completes directory names.
+func CompleteDirs() CompletionFunc {
+	return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	}
+}
+
+// CompleteValues returns a CompletionFunc that completes the given values.
+func CompleteValues(values ...string) CompletionFunc {
+	return func(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		var matches []string
+		for _, v := range values {
+			if strings.HasPrefix(v, toComplete) {
+				matches = append(matches, v)
+			}
+		}
+		return matches, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completionFunc returns the CompletionFunc for the option described by tag.
+// The complete key is one of:
+//
+// - file or file:<ext>,<ext>: file names, optionally filtered by extension
+// - dir: directory names
+// - enum:<value>,<value>: the listed values
+// - <name>: a CompletionFunc registered via RegisterCompleter
+//
+// Options without the complete key complete the values allowed by their
+// oneof or enum validation rules. Nil is returned if no completion applies.
+func completionFunc(tag map[string]string) (CompletionFunc, error) {
+	s, ok := tag["complete"]
+	if !ok {
+		for _, key := range []string{"oneof", "enum"} {
+			if values, ok := tag[key]; ok {
+				return completeList(values)
+			}
+		}
+		return nil, nil
+	}
+
+	kind, arg := s, ""
+	if idx := strings.Index(s, ":"); idx >= 0 {
+		kind, arg = s[:idx], s[idx+1:]
+	}
+
+	switch kind {
+	case "file":
+		if arg == "" {
+			return CompleteFiles(), nil
+		}
+		return CompleteFiles(strings.Split(arg, ",")...), nil
+	case "dir":
+		return CompleteDirs(), nil
+	case "enum":
+		return completeList(arg)
+	}
+
+	completersMu.RLock()
+	fn, ok := completers[s]
+	completersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", s, ErrCompleterNotRegistered)
+	}
+	return fn, nil
+}
+
+// argsCompletionFunc returns a CompletionFunc for cobra.Command.ValidArgsFunction
+// that completes each positional argument with its option's CompletionFunc.
+// The rest argument's CompletionFunc only applies past every positional
+// argument, and arguments without one aren't completed.
+func argsCompletionFunc(specs []argSpec) CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		var rest *argSpec
+		for idx, spec := range specs {
+			switch {
+			case spec.rest:
+				rest = &specs[idx]
+			case spec.pos == len(args):
+				if spec.complete == nil {
+					return nil, cobra.ShellCompDirectiveNoFileComp
+				}
+				return spec.complete(cmd, args, toComplete)
+			}
+		}
+		if rest != nil && rest.complete != nil {
+			return rest.complete(cmd, args, toComplete)
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeList returns a CompletionFunc for comma separated values, which are
+// parsed like the values of oneof and enum validation rules.
+func completeList(s string) (CompletionFunc, error) {
+	values, err := ParseStringSlice(s)
+	if err != nil {
+		return nil, fmt.Errorf("completion values: %w", err)
+	}
+	return CompleteValues(values...), nil
+}
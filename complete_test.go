@@ -0,0 +1,118 @@
+package cliutil_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cpliakas/cliutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type CompleteInput struct {
+	Config  string `cliutil:"option=config complete=file:yaml,yml"`
+	Dir     string `cliutil:"option=dir complete=dir"`
+	Level   string `cliutil:"option=level complete=enum:debug,info,error"`
+	Format  string `cliutil:"option=format oneof=json,yaml"`
+	Size    string `cliutil:"option=size oneof='small,\"medium\",large'"`
+	Cluster string `cliutil:"option=cluster complete=clusters"`
+	Target  string `cliutil:"option=target arg=0 complete=enum:dev,prod"`
+}
+
+func complete(t *testing.T, args ...string) string {
+	cliutil.RegisterCompleter("clusters", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return []string{"east", "west"}, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	rootCmd := &cobra.Command{Use: "root"}
+	cmd := &cobra.Command{Use: "test", Run: func(cmd *cobra.Command, args []string) {}}
+	rootCmd.AddCommand(cmd)
+	if err := cliutil.NewFlagger(cmd, viper.New()).SetOptions(&CompleteInput{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetArgs(append([]string{cobra.ShellCompRequestCmd, "test"}, args...))
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestSetOptionsCompletion(t *testing.T) {
+	tests := []struct {
+		args []string
+		ex   string
+	}{
+		{[]string{"--config", ""}, "yaml\nyml\n:8\n"},
+		{[]string{"--dir", ""}, ":16\n"},
+		{[]string{"--level", "d"}, "debug\n:4\n"},
+		{[]string{"--format", ""}, "json\nyaml\n:4\n"},
+		{[]string{"--size", "m"}, "medium\n:4\n"},
+		{[]string{"--cluster", ""}, "east\nwest\n:4\n"},
+		{[]string{"p"}, "prod\n:4\n"},
+	}
+
+	for _, tt := range tests {
+		out := complete(t, tt.args...)
+		// Strip cobra's debug message that follows the directive.
+		if idx := strings.Index(out, "Completion ended"); idx >= 0 {
+			out = out[:idx]
+		}
+		if out != tt.ex {
+			t.Errorf("%v: got %q, expected %q", tt.args, out, tt.ex)
+		}
+	}
+}
+
+func TestSetOptionsCompleterNotRegistered(t *testing.T) {
+	type Input struct {
+		Name string `cliutil:"option=name complete=missing"`
+	}
+
+	cmd := &cobra.Command{Use: "test"}
+	err := cliutil.NewFlagger(cmd, viper.New()).SetOptions(&Input{})
+	if !errors.Is(err, cliutil.ErrCompleterNotRegistered) {
+		t.Errorf("got %v, expected %v", err, cliutil.ErrCompleterNotRegistered)
+	}
+}
+
+func TestSetOptionsArgsCompletionRest(t *testing.T) {
+	type Input struct {
+		Source string   `cliutil:"option=source arg=0"`
+		Files  []string `cliutil:"option=files arg=rest complete=file:yaml"`
+	}
+
+	tests := []struct {
+		args []string
+		ex   string
+	}{
+		{[]string{""}, ":4\n"},
+		{[]string{"src", ""}, "yaml\n:8\n"},
+	}
+
+	for _, tt := range tests {
+		cmd := &cobra.Command{Use: "test", Run: func(cmd *cobra.Command, args []string) {}}
+		if err := cliutil.NewFlagger(cmd, viper.New()).SetOptions(&Input{}); err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		cmd.SetOut(&buf)
+		cmd.SetArgs(append([]string{cobra.ShellCompRequestCmd}, tt.args...))
+		if err := cmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+
+		out := buf.String()
+		if idx := strings.Index(out, "Completion ended"); idx >= 0 {
+			out = out[:idx]
+		}
+		if out != tt.ex {
+			t.Errorf("%v: got %q, expected %q", tt.args, out, tt.ex)
+		}
+	}
+}
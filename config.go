@@ -0,0 +1,249 @@
+package cliutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"weak"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// ConfigExts contains the config file extensions that are searched for in
+// order of preference. Only the first file found in each directory is used.
+var ConfigExts = []string{"yaml", "yml", "toml", "json"}
+
+// configMeta contains what cliutil knows about a *viper.Viper.
+type configMeta struct {
+	envPrefix string
+	hasEnv    bool
+	files     []string
+	sources   map[string]string
+	profile   string
+	cmd       weak.Pointer[cobra.Command]
+	groups    []flagGroup
+	envs      map[string][]string
+	args      map[string]bool
+}
+
+// The metadata is keyed by weak pointers and removed once the configuration
+// or command it describes is garbage collected, so it never keeps them alive.
+var (
+	cfgmetaMu sync.RWMutex
+	cfgmeta   = make(map[weak.Pointer[viper.Viper]]*configMeta)
+
+	// cmdcfg maps commands to the configuration last passed to NewFlagger
+	// with them.
+	cmdcfg = make(map[weak.Pointer[cobra.Command]]weak.Pointer[viper.Viper])
+)
+
+// getConfigMeta returns the metadata for cfg, initializing it if necessary.
+// Callers must hold cfgmetaMu.
+func getConfigMeta(cfg *viper.Viper) *configMeta {
+	key := weak.Make(cfg)
+	meta, ok := cfgmeta[key]
+	if !ok {
+		meta = &configMeta{}
+		cfgmeta[key] = meta
+		runtime.AddCleanup(cfg, func(key weak.Pointer[viper.Viper]) {
+			cfgmetaMu.Lock()
+			defer cfgmetaMu.Unlock()
+			delete(cfgmeta, key)
+		}, key)
+	}
+	return meta
+}
+
+// lookupConfigMeta returns the metadata for cfg, if any. Callers must hold
+// cfgmetaMu.
+func lookupConfigMeta(cfg *viper.Viper) (*configMeta, bool) {
+	meta, ok := cfgmeta[weak.Make(cfg)]
+	return meta, ok
+}
+
+// setCommandConfig records cfg as the configuration of cmd. Callers must hold
+// cfgmetaMu.
+func setCommandConfig(cmd *cobra.Command, cfg *viper.Viper) {
+	key := weak.Make(cmd)
+	if _, ok := cmdcfg[key]; !ok {
+		runtime.AddCleanup(cmd, func(key weak.Pointer[cobra.Command]) {
+			cfgmetaMu.Lock()
+			delete(cmdcfg, key)
+			cfgmetaMu.Unlock()
+
+			cmdoptsMu.Lock()
+			delete(cmdopts, key)
+			cmdoptsMu.Unlock()
+		}, key)
+	}
+	cmdcfg[key] = weak.Make(cfg)
+	getConfigMeta(cfg).cmd = key
+}
+
+// InitConfigWithFiles returns a *viper.Viper initialized by InitConfig that
+// has the config files returned by ConfigFiles(app) loaded into it. The
+// profile selected via the <PREFIX>_PROFILE environment variable or the
+// profile key of the config files is applied.
+func InitConfigWithFiles(envPrefix, app string) (*viper.Viper, error) {
+	cfg := InitConfig(envPrefix)
+	if err := LoadConfigFiles(cfg, ConfigFiles(app)...); err != nil {
+		return nil, err
+	}
+	if err := ApplyProfile(cfg, cfg.GetString(OptionProfile)); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// ConfigFiles searches for <app>.yaml, <app>.yml, <app>.toml or <app>.json
+// and returns the files that exist in order of increasing precedence:
+//
+//   - system: /etc/<app>/ and <dir>/<app>/ for each $XDG_CONFIG_DIRS entry,
+//     which defaults to /etc/xdg
+//   - user: $HOME/.<app>.<ext> and $XDG_CONFIG_HOME/<app>/, which defaults to
+//     $HOME/.config/<app>/
+//   - project: the working directory
+func ConfigFiles(app string) []string {
+	var files []string
+	add := func(dir, name string) {
+		if f, ok := findConfigFile(dir, name); ok {
+			files = append(files, f)
+		}
+	}
+
+	// System config files. XDG dirs are listed in order of preference, so
+	// they are merged in reverse.
+	add(filepath.Join("/etc", app), app)
+	dirs := filepath.SplitList(os.Getenv("XDG_CONFIG_DIRS"))
+	if len(dirs) == 0 {
+		dirs = []string{"/etc/xdg"}
+	}
+	for idx := len(dirs) - 1; idx >= 0; idx-- {
+		add(filepath.Join(dirs[idx], app), app)
+	}
+
+	// User config files.
+	home, _ := os.UserHomeDir()
+	if home != "" {
+		add(home, "."+app)
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		add(filepath.Join(dir, app), app)
+	}
+
+	// Project config file.
+	if wd, err := os.Getwd(); err == nil {
+		add(wd, app)
+	}
+
+	return uniqueStrings(files)
+}
+
+// findConfigFile returns the first file in dir named name with one of the
+// ConfigExts extensions.
+func findConfigFile(dir, name string) (string, bool) {
+	for _, ext := range ConfigExts {
+		f := filepath.Join(dir, name+"."+ext)
+		if info, err := os.Stat(f); err == nil && !info.IsDir() {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+// uniqueStrings removes duplicates from s, keeping the last occurrence so
+// that precedence is preserved.
+func uniqueStrings(s []string) []string {
+	seen := make(map[string]bool, len(s))
+	out := make([]string, 0, len(s))
+	for idx := len(s) - 1; idx >= 0; idx-- {
+		if !seen[s[idx]] {
+			seen[s[idx]] = true
+			out = append([]string{s[idx]}, out...)
+		}
+	}
+	return out
+}
+
+// LoadConfigFiles replaces the configuration in cfg with files merged in
+// order, so values in later files override values in earlier ones. The files
+// are remembered so that the Reloader can load them again, and the file that
+// set each key is reported by ConfigSource.
+func LoadConfigFiles(cfg *viper.Viper, files ...string) error {
+	sources, err := readConfigFiles(cfg, files)
+	if err != nil {
+		return err
+	}
+
+	cfgmetaMu.Lock()
+	meta := getConfigMeta(cfg)
+	meta.files = files
+	meta.sources = sources
+	profile := meta.profile
+	meta.profile = ""
+	cfgmetaMu.Unlock()
+
+	// Apply the profile again, because the config files replaced it.
+	return ApplyProfile(cfg, profile)
+}
+
+// readConfigFiles replaces the configuration in cfg with files merged in
+// order and returns the file that set each key.
+func readConfigFiles(cfg *viper.Viper, files []string) (map[string]string, error) {
+	sources := make(map[string]string)
+	for idx, f := range files {
+		keys, err := configFileKeys(f)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			sources[key] = f
+		}
+
+		cfg.SetConfigFile(f)
+		if idx == 0 {
+			err = cfg.ReadInConfig()
+		} else {
+			err = cfg.MergeInConfig()
+		}
+		if err != nil {
+			return nil, NewConfigError(fmt.Errorf("error reading config file %s: %w", f, err))
+		}
+	}
+	return sources, nil
+}
+
+// configFileKeys returns the keys set in config file f.
+func configFileKeys(f string) ([]string, error) {
+	v := viper.New()
+	v.SetConfigFile(f)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, NewConfigError(fmt.Errorf("error reading config file %s: %w", f, err))
+	}
+	return v.AllKeys(), nil
+}
+
+// ConfigFilesUsed returns the files loaded into cfg by LoadConfigFiles.
+func ConfigFilesUsed(cfg *viper.Viper) []string {
+	cfgmetaMu.RLock()
+	defer cfgmetaMu.RUnlock()
+	if meta, ok := lookupConfigMeta(cfg); ok {
+		return meta.files
+	}
+	return nil
+}
+
+// ConfigSource returns the config file loaded by LoadConfigFiles that set
+// key, or an empty string if no file set it.
+func ConfigSource(cfg *viper.Viper, key string) string {
+	cfgmetaMu.RLock()
+	defer cfgmetaMu.RUnlock()
+	if meta, ok := lookupConfigMeta(cfg); ok {
+		return meta.sources[strings.ToLower(key)]
+	}
+	return ""
+}
@@ -0,0 +1,221 @@
+package cliutil_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/cpliakas/cliutil"
+	"github.com/go-test/deep"
+	"github.com/spf13/cobra"
+)
+
+// setupConfigDirs writes config files to system, user and project dirs in a
+// temporary directory and points the environment at them.
+func setupConfigDirs(t *testing.T) (string, func()) {
+	tmp, err := ioutil.TempDir(os.TempDir(), "cliutil-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"system/myapp/myapp.yaml": "name: system\nregion: us-east-1\ntimeout: 10s\n",
+		"user/myapp/myapp.toml":   "name = \"user\"\nregion = \"us-west-2\"\n",
+		"project/myapp.json":      `{"name": "project"}`,
+	}
+	for name, data := range files {
+		f := filepath.Join(tmp, name)
+		if err := os.MkdirAll(filepath.Dir(f), 0700); err != nil {
+			t.Fatal(err)
+		}
+		writeConfig(t, f, data)
+	}
+
+	wd, _ := os.Getwd()
+	env := map[string]string{
+		"HOME":            filepath.Join(tmp, "home"),
+		"XDG_CONFIG_DIRS": filepath.Join(tmp, "system"),
+		"XDG_CONFIG_HOME": filepath.Join(tmp, "user"),
+	}
+	orig := make(map[string]string)
+	for k, v := range env {
+		orig[k] = os.Getenv(k)
+		os.Setenv(k, v)
+	}
+	if err := os.Chdir(filepath.Join(tmp, "project")); err != nil {
+		t.Fatal(err)
+	}
+
+	return tmp, func() {
+		os.Chdir(wd)
+		for k, v := range orig {
+			os.Setenv(k, v)
+		}
+		os.RemoveAll(tmp)
+	}
+}
+
+func TestConfigFiles(t *testing.T) {
+	tmp, teardown := setupConfigDirs(t)
+	defer teardown()
+
+	// Resolve symlinks, e.g., /var -> /private/var on macOS.
+	tmp, _ = filepath.EvalSymlinks(tmp)
+
+	ex := []string{
+		filepath.Join(tmp, "system/myapp/myapp.yaml"),
+		filepath.Join(tmp, "user/myapp/myapp.toml"),
+		filepath.Join(tmp, "project/myapp.json"),
+	}
+
+	actual := cliutil.ConfigFiles("myapp")
+	for idx := range actual {
+		actual[idx], _ = filepath.EvalSymlinks(actual[idx])
+	}
+	if diff := deep.Equal(actual, ex); diff != nil {
+		t.Error(diff)
+	}
+}
+
+type ConfigInput struct {
+	Name    string `cliutil:"option=name"`
+	Region  string `cliutil:"option=region"`
+	Timeout string `cliutil:"option=timeout"`
+}
+
+func TestInitConfigWithFiles(t *testing.T) {
+	_, teardown := setupConfigDirs(t)
+	defer teardown()
+
+	v, err := cliutil.InitConfigWithFiles("CLIUTIL_TEST", "myapp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := &ConfigInput{}
+	if err := cliutil.ReadOptions(input, v); err != nil {
+		t.Fatal(err)
+	}
+
+	ex := &ConfigInput{Name: "project", Region: "us-west-2", Timeout: "10s"}
+	if diff := deep.Equal(input, ex); diff != nil {
+		t.Error(diff)
+	}
+
+	tests := []struct {
+		key  string
+		file string
+	}{
+		{"name", "myapp.json"},
+		{"region", "myapp.toml"},
+		{"timeout", "myapp.yaml"},
+		{"missing", ""},
+	}
+
+	for _, tt := range tests {
+		actual := cliutil.ConfigSource(v, tt.key)
+		if actual != "" {
+			actual = filepath.Base(actual)
+		}
+		if actual != tt.file {
+			t.Errorf("%s: got %q, expected %q", tt.key, actual, tt.file)
+		}
+	}
+
+	if actual := len(cliutil.ConfigFilesUsed(v)); actual != 3 {
+		t.Errorf("got %v files, expected 3", actual)
+	}
+}
+
+func TestLoadConfigFilesError(t *testing.T) {
+	tmpfile, err := ioutil.TempFile(os.TempDir(), "cliutil-*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpfile.Name())
+	tmpfile.Close()
+	writeConfig(t, tmpfile.Name(), "name: [unclosed\n")
+
+	err = cliutil.LoadConfigFiles(cliutil.InitConfig("CLIUTIL_TEST"), tmpfile.Name())
+	var exitErr *cliutil.ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != cliutil.ExitCodeConfig {
+		t.Errorf("got %v, expected config error", err)
+	}
+}
+
+type ConfigCollectionsInput struct {
+	Ports  []int             `cliutil:"option=ports"`
+	Tags   []string          `cliutil:"option=tags"`
+	Labels map[string]string `cliutil:"option=labels"`
+}
+
+func TestLoadConfigFilesCollections(t *testing.T) {
+	tmp, err := ioutil.TempDir(os.TempDir(), "cliutil-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	files := map[string]string{
+		"config.yaml": "ports: [80, 443]\ntags:\n- a\n- b\nlabels:\n  env: prod\n  replicas: 3\n",
+		"config.toml": "ports = [80, 443]\ntags = [\"a\", \"b\"]\n[labels]\nenv = \"prod\"\nreplicas = 3\n",
+		"config.json": `{"ports": [80, 443], "tags": ["a", "b"], "labels": {"env": "prod", "replicas": 3}}`,
+	}
+
+	ex := &ConfigCollectionsInput{
+		Ports:  []int{80, 443},
+		Tags:   []string{"a", "b"},
+		Labels: map[string]string{"env": "prod", "replicas": "3"},
+	}
+
+	for name, data := range files {
+		file := filepath.Join(tmp, name)
+		writeConfig(t, file, data)
+
+		v := cliutil.InitConfig("CLIUTIL_TEST")
+		if err := cliutil.LoadConfigFiles(v, file); err != nil {
+			t.Fatal(err)
+		}
+
+		input := &ConfigCollectionsInput{}
+		if err := cliutil.ReadOptions(input, v); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if diff := deep.Equal(input, ex); diff != nil {
+			t.Errorf("%s: %v", name, diff)
+		}
+	}
+}
+
+func TestConfigCollected(t *testing.T) {
+	collected := make(chan struct{})
+	func() {
+		v := cliutil.InitConfig("CLIUTIL_TEST")
+		input := &ConfigInput{}
+		cmd := &cobra.Command{
+			Use: "test",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return cliutil.ReadOptions(input, v)
+			},
+		}
+		if err := cliutil.NewFlagger(cmd, v).SetOptions(input); err != nil {
+			t.Fatal(err)
+		}
+		runtime.AddCleanup(v, func(ch chan struct{}) { close(ch) }, collected)
+	}()
+
+	// The metadata cliutil keeps must not keep the configuration alive.
+	for idx := 0; idx < 10; idx++ {
+		runtime.GC()
+		select {
+		case <-collected:
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	t.Error("expected the configuration to be garbage collected")
+}
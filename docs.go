@@ -0,0 +1,358 @@
+package cliutil
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"weak"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// docOption describes an option in generated documentation.
+type docOption struct {
+	name      string
+	shorthand string
+	usage     string
+	envVar    string
+	def       string
+	rules     []string
+	required  bool
+	arg       bool
+}
+
+// GenMarkdown writes Markdown reference documentation for cmd to w. The
+// documentation lists the command's arguments and options, including the
+// environment variables and configuration keys that set them, their
+// defaults and their validation rules.
+func GenMarkdown(cmd *cobra.Command, w io.Writer) error {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "# %s\n\n", cmd.CommandPath())
+	if cmd.Short != "" {
+		fmt.Fprintf(&buf, "%s\n\n", cmd.Short)
+	}
+	if cmd.Long != "" {
+		fmt.Fprintf(&buf, "%s\n\n", cmd.Long)
+	}
+	if cmd.Runnable() {
+		fmt.Fprintf(&buf, "## Usage\n\n```\n%s\n```\n\n", cmd.UseLine())
+	}
+	if cmd.Example != "" {
+		fmt.Fprintf(&buf, "## Examples\n\n```\n%s\n```\n\n", cmd.Example)
+	}
+
+	writeMarkdownTable(&buf, "Arguments", "Argument", docArgs(cmd))
+	writeMarkdownTable(&buf, "Options", "Flag", docFlags(cmd, cmd.NonInheritedFlags()))
+	writeMarkdownTable(&buf, "Inherited Options", "Flag", docFlags(cmd, cmd.InheritedFlags()))
+
+	if children := docCommands(cmd); len(children) > 0 {
+		buf.WriteString("## Commands\n\n")
+		for _, child := range children {
+			fmt.Fprintf(&buf, "* [%s](%s) - %s\n", child.CommandPath(), markdownFilename(child), child.Short)
+		}
+		buf.WriteString("\n")
+	}
+	if parent := cmd.Parent(); parent != nil {
+		fmt.Fprintf(&buf, "## See Also\n\n* [%s](%s) - %s\n", parent.CommandPath(), markdownFilename(parent), parent.Short)
+	}
+
+	_, err := w.Write(bytes.TrimRight(buf.Bytes(), "\n"))
+	if err == nil {
+		_, err = io.WriteString(w, "\n")
+	}
+	return err
+}
+
+// GenMarkdownTree writes Markdown reference documentation for cmd and all of
+// its subcommands to dir, one file per command.
+func GenMarkdownTree(cmd *cobra.Command, dir string) error {
+	return genTree(cmd, dir, markdownFilename, GenMarkdown)
+}
+
+// GenMan writes a man page for cmd to w in the given section, e.g., 1.
+func GenMan(cmd *cobra.Command, section string, w io.Writer) error {
+	var buf bytes.Buffer
+
+	title := strings.ToUpper(strings.Replace(cmd.CommandPath(), " ", "-", -1))
+	fmt.Fprintf(&buf, ".TH %q %q \"\" \"\" \"\"\n", title, section)
+
+	buf.WriteString(".SH NAME\n")
+	fmt.Fprintf(&buf, "%s \\- %s\n", roffEscape(strings.Replace(cmd.CommandPath(), " ", "-", -1)), roffEscape(cmd.Short))
+
+	if cmd.Runnable() {
+		fmt.Fprintf(&buf, ".SH SYNOPSIS\n\\fB%s\\fP\n", roffEscape(cmd.UseLine()))
+	}
+	if desc := cmd.Long; desc != "" || cmd.Short != "" {
+		if desc == "" {
+			desc = cmd.Short
+		}
+		fmt.Fprintf(&buf, ".SH DESCRIPTION\n%s\n", roffEscape(desc))
+	}
+
+	writeManOptions(&buf, "ARGUMENTS", docArgs(cmd))
+	writeManOptions(&buf, "OPTIONS", docFlags(cmd, cmd.NonInheritedFlags()))
+	writeManOptions(&buf, "INHERITED OPTIONS", docFlags(cmd, cmd.InheritedFlags()))
+
+	var env []docOption
+	for _, opts := range [][]docOption{docArgs(cmd), docFlags(cmd, cmd.NonInheritedFlags()), docFlags(cmd, cmd.InheritedFlags())} {
+		for _, opt := range opts {
+			if opt.envVar != "" {
+				env = append(env, opt)
+			}
+		}
+	}
+	if len(env) > 0 {
+		buf.WriteString(".SH ENVIRONMENT\n")
+		for _, opt := range env {
+			fmt.Fprintf(&buf, ".TP\n\\fB%s\\fP\nSets %s.\n", roffEscape(opt.envVar), roffEscape(docName(opt)))
+		}
+	}
+
+	if cmd.Example != "" {
+		fmt.Fprintf(&buf, ".SH EXAMPLES\n.nf\n%s\n.fi\n", roffEscape(cmd.Example))
+	}
+
+	var seeAlso []string
+	if parent := cmd.Parent(); parent != nil {
+		seeAlso = append(seeAlso, manRef(parent, section))
+	}
+	for _, child := range docCommands(cmd) {
+		seeAlso = append(seeAlso, manRef(child, section))
+	}
+	if len(seeAlso) > 0 {
+		fmt.Fprintf(&buf, ".SH SEE ALSO\n%s\n", strings.Join(seeAlso, ", "))
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// GenManTree writes man pages for cmd and all of its subcommands to dir, one
+// file per command.
+func GenManTree(cmd *cobra.Command, section, dir string) error {
+	filename := func(c *cobra.Command) string {
+		return strings.Replace(c.CommandPath(), " ", "-", -1) + "." + section
+	}
+	return genTree(cmd, dir, filename, func(c *cobra.Command, w io.Writer) error {
+		return GenMan(c, section, w)
+	})
+}
+
+// genTree writes the documentation for cmd and its subcommands to dir.
+func genTree(cmd *cobra.Command, dir string, filename func(*cobra.Command) string, gen func(*cobra.Command, io.Writer) error) error {
+	for _, child := range docCommands(cmd) {
+		if err := genTree(child, dir, filename, gen); err != nil {
+			return err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := gen(cmd, &buf); err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, filename(cmd))
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+	return nil
+}
+
+// docCommands returns the subcommands of cmd that are documented.
+func docCommands(cmd *cobra.Command) (children []*cobra.Command) {
+	for _, child := range cmd.Commands() {
+		if child.IsAvailableCommand() && !child.IsAdditionalHelpTopicCommand() {
+			children = append(children, child)
+		}
+	}
+	return
+}
+
+// docArgs returns the positional arguments of cmd.
+func docArgs(cmd *cobra.Command) (opts []docOption) {
+	cfg := commandConfig(cmd)
+	for _, info := range CommandOptions(cmd) {
+		if info.Arg == "" {
+			continue
+		}
+		opts = append(opts, docOption{
+			name:     info.Name,
+			usage:    info.Usage,
+			envVar:   EnvVar(cfg, info.Name),
+			def:      info.Default,
+			rules:    info.Rules,
+			required: info.Required,
+			arg:      true,
+		})
+	}
+	return
+}
+
+// docFlags returns the visible flags in flags. Options set via SetOptions on
+// cmd or its parents contribute their validation rules.
+func docFlags(cmd *cobra.Command, flags *pflag.FlagSet) (opts []docOption) {
+	cfg := commandConfig(cmd)
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Hidden || flag.Name == "help" {
+			return
+		}
+
+		opt := docOption{
+			name:      flag.Name,
+			shorthand: flag.Shorthand,
+			usage:     flag.Usage,
+			envVar:    EnvVar(cfg, flag.Name),
+			def:       flag.DefValue,
+			required:  len(flag.Annotations[cobra.BashCompOneRequiredFlag]) > 0,
+		}
+		if info, ok := findOptionInfo(cmd, flag.Name); ok {
+			opt.def = info.Default
+			opt.rules = info.Rules
+			opt.required = opt.required || info.Required
+		} else if isZeroDefault(flag.DefValue) {
+			opt.def = ""
+		}
+		opts = append(opts, opt)
+	})
+	return
+}
+
+// findOptionInfo returns the OptionInfo of the named option set on cmd or
+// the nearest parent that sets it.
+func findOptionInfo(cmd *cobra.Command, name string) (OptionInfo, bool) {
+	for c := cmd; c != nil; c = c.Parent() {
+		if info, ok := lookupOptionInfo(c, name); ok {
+			return info, true
+		}
+	}
+	return OptionInfo{}, false
+}
+
+// commandConfig returns the configuration that was last passed to NewFlagger
+// with cmd, or nil if there isn't any.
+func commandConfig(cmd *cobra.Command) *viper.Viper {
+	cfgmetaMu.RLock()
+	defer cfgmetaMu.RUnlock()
+	return cmdcfg[weak.Make(cmd)].Value()
+}
+
+// isZeroDefault returns true if a flag's default is the zero value of its
+// type, which isn't worth documenting.
+func isZeroDefault(s string) bool {
+	switch s {
+	case "", "false", "0", "0s", "[]", "map[]":
+		return true
+	}
+	return false
+}
+
+// docName returns the option formatted as a flag or argument.
+func docName(opt docOption) string {
+	if opt.arg {
+		return strings.ToUpper(opt.name)
+	}
+	return "--" + opt.name
+}
+
+// docDescription returns the option's usage followed by its constraints.
+func docDescription(opt docOption) string {
+	desc := strings.TrimSpace(opt.usage)
+	if opt.required {
+		desc = strings.TrimSpace(desc + " (required)")
+	}
+	return desc
+}
+
+func writeMarkdownTable(buf *bytes.Buffer, title, column string, opts []docOption) {
+	if len(opts) == 0 {
+		return
+	}
+
+	fmt.Fprintf(buf, "## %s\n\n", title)
+	fmt.Fprintf(buf, "| %s | Description | Environment | Config Key | Default | Validation |\n", column)
+	buf.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	for _, opt := range opts {
+		name := "`" + docName(opt) + "`"
+		if opt.shorthand != "" {
+			name = "`-" + opt.shorthand + "`, " + name
+		}
+		cells := []string{
+			name,
+			markdownEscape(docDescription(opt)),
+			markdownCode(opt.envVar),
+			markdownCode(opt.name),
+			markdownCode(opt.def),
+			markdownEscape(strings.Join(opt.rules, ", ")),
+		}
+		fmt.Fprintf(buf, "| %s |\n", strings.Join(cells, " | "))
+	}
+	buf.WriteString("\n")
+}
+
+func markdownFilename(cmd *cobra.Command) string {
+	return strings.Replace(cmd.CommandPath(), " ", "_", -1) + ".md"
+}
+
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + markdownEscape(s) + "`"
+}
+
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+func writeManOptions(buf *bytes.Buffer, title string, opts []docOption) {
+	if len(opts) == 0 {
+		return
+	}
+
+	fmt.Fprintf(buf, ".SH %s\n", title)
+	for _, opt := range opts {
+		name := `\fB` + roffEscape(docName(opt)) + `\fP`
+		if opt.shorthand != "" {
+			name = `\fB\-` + roffEscape(opt.shorthand) + `\fP, ` + name
+		}
+		fmt.Fprintf(buf, ".TP\n%s\n%s\n", name, roffEscape(docDescription(opt)))
+
+		var details []string
+		if opt.envVar != "" {
+			details = append(details, "Environment: "+opt.envVar)
+		}
+		details = append(details, "Config key: "+opt.name)
+		if opt.def != "" {
+			details = append(details, "Default: "+opt.def)
+		}
+		if len(opt.rules) > 0 {
+			details = append(details, "Validation: "+strings.Join(opt.rules, ", "))
+		}
+		for _, detail := range details {
+			fmt.Fprintf(buf, ".br\n%s\n", roffEscape(detail))
+		}
+	}
+}
+
+func manRef(cmd *cobra.Command, section string) string {
+	return fmt.Sprintf(`\fB%s\fP(%s)`, roffEscape(strings.Replace(cmd.CommandPath(), " ", "-", -1)), section)
+}
+
+// roffEscape escapes backslashes and dashes and prevents lines from being
+// interpreted as roff requests.
+func roffEscape(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+	lines := strings.Split(s, "\n")
+	for idx, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[idx] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,103 @@
+package cliutil_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cpliakas/cliutil"
+	"github.com/spf13/cobra"
+)
+
+type DocsGlobalInput struct {
+	Region string `cliutil:"option=region persistent default=us usage='region to deploy to'"`
+}
+
+type DocsDeployInput struct {
+	Target   string `cliutil:"option=target arg=0 required usage='deployment target'"`
+	Replicas int    `cliutil:"option=replicas short=r default=2 min=1 max=10 usage='number of replicas'"`
+	Token    string `cliutil:"option=token secret required usage='API token'"`
+}
+
+func TestGenMarkdown(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "myapp", Short: "My application"}
+	if err := cliutil.NewFlagger(rootCmd, cliutil.InitConfig("MYAPP")).SetOptions(&DocsGlobalInput{}); err != nil {
+		t.Fatal(err)
+	}
+
+	deployCmd := &cobra.Command{Use: "deploy", Short: "Deploy the app", Run: func(*cobra.Command, []string) {}}
+	_, flags := cliutil.AddCommand(rootCmd, deployCmd, "MYAPP")
+	if err := flags.SetOptions(&DocsDeployInput{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := cliutil.GenMarkdown(deployCmd, &buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, ex := range []string{
+		"# myapp deploy\n",
+		"myapp deploy TARGET [flags]",
+		"| `TARGET` | deployment target (required) | `MYAPP_TARGET` | `target` |  |  |",
+		"| `-r`, `--replicas` | number of replicas | `MYAPP_REPLICAS` | `replicas` | `2` | min=1, max=10 |",
+		"| `--token` | API token (required) | `MYAPP_TOKEN` | `token` |  |  |",
+		"## Inherited Options",
+		"| `--region` | region to deploy to | `MYAPP_REGION` | `region` | `us` |  |",
+		"* [myapp](myapp.md) - My application",
+	} {
+		if !strings.Contains(out, ex) {
+			t.Errorf("expected output to contain %q, got:\n%s", ex, out)
+		}
+	}
+}
+
+func TestGenManTree(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "myapp", Short: "My application"}
+	if err := cliutil.NewFlagger(rootCmd, cliutil.InitConfig("MYAPP")).SetOptions(&DocsGlobalInput{}); err != nil {
+		t.Fatal(err)
+	}
+
+	deployCmd := &cobra.Command{Use: "deploy", Short: "Deploy the app", Run: func(*cobra.Command, []string) {}}
+	_, flags := cliutil.AddCommand(rootCmd, deployCmd, "MYAPP")
+	if err := flags.SetOptions(&DocsDeployInput{}); err != nil {
+		t.Fatal(err)
+	}
+
+	tmp, err := ioutil.TempDir(os.TempDir(), "cliutil-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := cliutil.GenManTree(rootCmd, "1", tmp); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(tmp, "myapp-deploy.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(b)
+
+	for _, ex := range []string{
+		`.TH "MYAPP-DEPLOY" "1"`,
+		`\fB\-r\fP, \fB\-\-replicas\fP`,
+		"Environment: MYAPP_REPLICAS",
+		"Validation: min=1, max=10",
+		".SH ENVIRONMENT",
+		`\fBmyapp\fP(1)`,
+	} {
+		if !strings.Contains(out, ex) {
+			t.Errorf("expected output to contain %q, got:\n%s", ex, out)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(tmp, "myapp.1")); err != nil {
+		t.Error(err)
+	}
+}
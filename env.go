@@ -0,0 +1,129 @@
+package cliutil
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// EnvAnnotation is the flag annotation that lists the environment variables
+// that set the flag's option.
+const EnvAnnotation = "cliutil_env"
+
+// bindFlag binds flag to the Flagger's config and annotates it with the
+// environment variables that set it.
+func (f *Flagger) bindFlag(flag *pflag.Flag) {
+	f.cfg.BindPFlag(flag.Name, flag)
+	annotateEnv(flag, EnvVars(f.cfg, flag.Name))
+}
+
+// annotateEnv sets the EnvAnnotation of flag.
+func annotateEnv(flag *pflag.Flag, names []string) {
+	if flag == nil || len(names) == 0 {
+		return
+	}
+	if flag.Annotations == nil {
+		flag.Annotations = make(map[string][]string)
+	}
+	flag.Annotations[EnvAnnotation] = names
+}
+
+// BindEnv sets the environment variables that set the named option, which
+// are read in order if the previous one isn't set. It is also set via the env
+// tag key, e.g., env=DATABASE_URL,DB_URL. Configs initialized by InitConfig
+// read the name derived from the option first, so EnvVars lists it before
+// envVars.
+func (f *Flagger) BindEnv(name string, envVars ...string) {
+	if len(envVars) == 0 {
+		return
+	}
+
+	cfgmetaMu.Lock()
+	meta := getConfigMeta(f.cfg)
+	if meta.envs == nil {
+		meta.envs = make(map[string][]string)
+	}
+	meta.envs[name] = envVars
+	cfgmetaMu.Unlock()
+
+	f.cfg.BindEnv(name, envVars[0])
+	annotateEnv(f.lookupFlag(name), envVars)
+}
+
+// bindEnvAliases binds each option with aliases to the first of its
+// environment variables that is set, because viper only reads one variable
+// per key.
+func bindEnvAliases(cfg *viper.Viper) {
+	cfgmetaMu.RLock()
+	envs := make(map[string][]string)
+	if meta, ok := lookupConfigMeta(cfg); ok {
+		for name, names := range meta.envs {
+			envs[name] = names
+		}
+	}
+	cfgmetaMu.RUnlock()
+
+	for name, names := range envs {
+		for _, env := range names {
+			if _, ok := os.LookupEnv(env); ok {
+				cfg.BindEnv(name, env)
+				break
+			}
+		}
+	}
+}
+
+// FlagUsagesWithEnv returns the usage of the flags in fs like
+// pflag.FlagSet.FlagUsages with an additional column that lists the
+// environment variables that set each flag.
+func FlagUsagesWithEnv(fs *pflag.FlagSet) string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 3, ' ', 0)
+
+	fmt.Fprintln(w, "  FLAG\tDESCRIPTION\tENVIRONMENT")
+	fs.VisitAll(func(flag *pflag.Flag) {
+		if flag.Hidden {
+			return
+		}
+
+		name := "      --" + flag.Name
+		if flag.Shorthand != "" && flag.ShorthandDeprecated == "" {
+			name = "  -" + flag.Shorthand + ", --" + flag.Name
+		}
+		varname, usage := pflag.UnquoteUsage(flag)
+		if varname != "" {
+			name += " " + varname
+		}
+		if !isZeroDefault(flag.DefValue) {
+			if flag.Value.Type() == "string" {
+				usage += fmt.Sprintf(" (default %q)", flag.DefValue)
+			} else {
+				usage += fmt.Sprintf(" (default %s)", flag.DefValue)
+			}
+		}
+		env := strings.Join(flag.Annotations[EnvAnnotation], ", ")
+
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, usage, env)
+	})
+
+	w.Flush()
+	return buf.String()
+}
+
+// SetEnvUsageTemplate sets the usage template of cmd, which subcommands
+// inherit, to one that prints the environment variables of flags via
+// FlagUsagesWithEnv.
+func SetEnvUsageTemplate(cmd *cobra.Command) {
+	cobra.AddTemplateFunc("flagUsagesWithEnv", FlagUsagesWithEnv)
+	tmpl := strings.NewReplacer(
+		".LocalFlags.FlagUsages", "flagUsagesWithEnv .LocalFlags",
+		".InheritedFlags.FlagUsages", "flagUsagesWithEnv .InheritedFlags",
+	).Replace(cmd.UsageTemplate())
+	cmd.SetUsageTemplate(tmpl)
+}
@@ -0,0 +1,90 @@
+package cliutil_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cpliakas/cliutil"
+	"github.com/go-test/deep"
+)
+
+type EnvInput struct {
+	MaxNum int    `cliutil:"option=max-num default=10 usage='maximum number'"`
+	DBURL  string `cliutil:"option=db-url env=CLIUTIL_TEST_DATABASE_URL,DATABASE_URL usage='database url'"`
+}
+
+func TestFlaggerEnvAnnotation(t *testing.T) {
+	cmd := newTestCommand(t, cliutil.InitConfig("CLIUTIL_TEST"), &EnvInput{})
+
+	tests := []struct {
+		flag string
+		ex   []string
+	}{
+		{"max-num", []string{"CLIUTIL_TEST_MAX_NUM"}},
+		{"db-url", []string{"CLIUTIL_TEST_DB_URL", "CLIUTIL_TEST_DATABASE_URL", "DATABASE_URL"}},
+	}
+	for _, tt := range tests {
+		actual := cmd.Flags().Lookup(tt.flag).Annotations[cliutil.EnvAnnotation]
+		if diff := deep.Equal(actual, tt.ex); diff != nil {
+			t.Errorf("%s: %v", tt.flag, diff)
+		}
+	}
+}
+
+func TestReadOptionsEnvAlias(t *testing.T) {
+	os.Setenv("DATABASE_URL", "postgres://alias")
+	defer os.Unsetenv("DATABASE_URL")
+
+	input := &EnvInput{}
+	v := cliutil.InitConfig("CLIUTIL_TEST")
+	newTestCommand(t, v, input)
+	if err := cliutil.ReadOptions(input, v); err != nil {
+		t.Fatal(err)
+	}
+	if ex := "postgres://alias"; input.DBURL != ex {
+		t.Errorf("got %q, expected %q", input.DBURL, ex)
+	}
+
+	os.Setenv("CLIUTIL_TEST_DATABASE_URL", "postgres://primary")
+	defer os.Unsetenv("CLIUTIL_TEST_DATABASE_URL")
+
+	if err := cliutil.ReadOptions(input, v); err != nil {
+		t.Fatal(err)
+	}
+	if ex := "postgres://primary"; input.DBURL != ex {
+		t.Errorf("got %q, expected %q", input.DBURL, ex)
+	}
+}
+
+func TestReadOptionsEnvPrecedence(t *testing.T) {
+	os.Setenv("CLIUTIL_TEST_DB_URL", "postgres://derived")
+	defer os.Unsetenv("CLIUTIL_TEST_DB_URL")
+	os.Setenv("CLIUTIL_TEST_DATABASE_URL", "postgres://override")
+	defer os.Unsetenv("CLIUTIL_TEST_DATABASE_URL")
+
+	input := &EnvInput{}
+	v := cliutil.InitConfig("CLIUTIL_TEST")
+	cmd := newTestCommand(t, v, input)
+	if err := cliutil.ReadOptions(input, v); err != nil {
+		t.Fatal(err)
+	}
+
+	// The value is read from the first variable listed in help output.
+	names := cmd.Flags().Lookup("db-url").Annotations[cliutil.EnvAnnotation]
+	if ex := "postgres://derived"; input.DBURL != ex || names[0] != "CLIUTIL_TEST_DB_URL" {
+		t.Errorf("got %q from %v, expected %q from CLIUTIL_TEST_DB_URL", input.DBURL, names, ex)
+	}
+}
+
+func TestSetEnvUsageTemplate(t *testing.T) {
+	cmd := newTestCommand(t, cliutil.InitConfig("CLIUTIL_TEST"), &EnvInput{})
+	cliutil.SetEnvUsageTemplate(cmd)
+
+	usage := cmd.UsageString()
+	for _, ex := range []string{"ENVIRONMENT", "--max-num int", "maximum number (default 10)", "CLIUTIL_TEST_MAX_NUM", "CLIUTIL_TEST_DB_URL, CLIUTIL_TEST_DATABASE_URL, DATABASE_URL"} {
+		if !strings.Contains(usage, ex) {
+			t.Errorf("expected usage to contain %q, got:\n%s", ex, usage)
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package cliutil
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -15,14 +18,15 @@ import (
 //
 
 // HandleError either performs a no-op if err is nil or writes the error plus
-// command usage to os.Stderr and exits with a non-zero status otherwise.
+// command usage to os.Stderr and exits with a non-zero status otherwise. The
+// exit status is determined by ExitCode.
 func HandleError(cmd *cobra.Command, err error, prefixes ...string) {
 	if err == nil {
 		return
 	}
 	WriteError(os.Stderr, err, prefixes...)
 	cmd.Usage()
-	os.Exit(1)
+	os.Exit(ExitCode(err))
 }
 
 // WriteError formats and writes an error message to io.Writer w. All prefixes
@@ -37,3 +41,155 @@ func WriteError(w io.Writer, err error, prefixes ...string) {
 	format += "%v\n\n"
 	fmt.Fprintf(w, format, err)
 }
+
+// OptionError is an error related to a single option. It records the flag
+// name and, if known, the environment variable the option can be set with.
+type OptionError struct {
+	Option string
+	EnvVar string
+	Err    error
+}
+
+// Error implements error.Error.
+func (e *OptionError) Error() string {
+	if e.EnvVar == "" {
+		return fmt.Sprintf("--%s: %v", e.Option, e.Err)
+	}
+	return fmt.Sprintf("--%s (%s): %v", e.Option, e.EnvVar, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *OptionError) Unwrap() error { return e.Err }
+
+// OptionErrors aggregates errors for multiple options so that every problem
+// can be reported at once.
+type OptionErrors []*OptionError
+
+// Error implements error.Error.
+func (e OptionErrors) Error() string {
+	msgs := make([]string, len(e))
+	for idx, err := range e {
+		msgs[idx] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is returns true if any of the option errors matches target.
+func (e OptionErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExitCode* constants contain the exit codes for each class of error. They
+// follow the conventions of sysexits.h, timeout(1) and the shell.
+const (
+	ExitCodeOK          = 0
+	ExitCodeError       = 1
+	ExitCodeUsage       = 64
+	ExitCodeNotFound    = 66
+	ExitCodePermission  = 77
+	ExitCodeConfig      = 78
+	ExitCodeTimeout     = 124
+	ExitCodeInterrupted = 130
+)
+
+// Category* constants contain the names of the classes of errors.
+const (
+	CategoryError       = "error"
+	CategoryUsage       = "usage"
+	CategoryNotFound    = "not found"
+	CategoryPermission  = "permission denied"
+	CategoryConfig      = "config"
+	CategoryTimeout     = "timeout"
+	CategoryInterrupted = "interrupted"
+)
+
+// ExitError wraps an error with the exit code and category that is used when
+// the process exits because of it.
+type ExitError struct {
+	Code     int
+	Category string
+	Err      error
+}
+
+// NewExitError returns an error that wraps err with an exit code and
+// category. Nil is returned if err is nil.
+func NewExitError(code int, category string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ExitError{Code: code, Category: category, Err: err}
+}
+
+// Error implements error.Error.
+func (e *ExitError) Error() string { return e.Err.Error() }
+
+// Unwrap returns the underlying error.
+func (e *ExitError) Unwrap() error { return e.Err }
+
+// NewUsageError classifies err as an invalid use of the command.
+func NewUsageError(err error) error { return NewExitError(ExitCodeUsage, CategoryUsage, err) }
+
+// NewConfigError classifies err as an error in the configuration.
+func NewConfigError(err error) error { return NewExitError(ExitCodeConfig, CategoryConfig, err) }
+
+// NewNotFoundError classifies err as a resource that doesn't exist.
+func NewNotFoundError(err error) error {
+	return NewExitError(ExitCodeNotFound, CategoryNotFound, err)
+}
+
+// NewPermissionError classifies err as a lack of permissions.
+func NewPermissionError(err error) error {
+	return NewExitError(ExitCodePermission, CategoryPermission, err)
+}
+
+// NewTimeoutError classifies err as an operation that timed out.
+func NewTimeoutError(err error) error {
+	return NewExitError(ExitCodeTimeout, CategoryTimeout, err)
+}
+
+// NewInterruptedError classifies err as an operation that was interrupted.
+func NewInterruptedError(err error) error {
+	return NewExitError(ExitCodeInterrupted, CategoryInterrupted, err)
+}
+
+// ExitCode returns the exit code for err. The code of the first ExitError in
+// the chain is used if there is one. Otherwise common errors are classified,
+// e.g., os.ErrNotExist exits with ExitCodeNotFound and missing or invalid
+// options exit with ExitCodeUsage. ExitCodeOK is returned if err is nil.
+func ExitCode(err error) int {
+	code, _ := classify(err)
+	return code
+}
+
+// ErrorCategory returns the category of err as determined by ExitCode.
+func ErrorCategory(err error) string {
+	_, category := classify(err)
+	return category
+}
+
+func classify(err error) (int, string) {
+	var exitErr *ExitError
+	switch {
+	case err == nil:
+		return ExitCodeOK, ""
+	case errors.As(err, &exitErr):
+		return exitErr.Code, exitErr.Category
+	case errors.Is(err, ErrRequired), errors.Is(err, ErrZeroValue), errors.Is(err, ErrInvalid), errors.Is(err, ErrFlagGroup):
+		return ExitCodeUsage, CategoryUsage
+	case errors.Is(err, os.ErrNotExist):
+		return ExitCodeNotFound, CategoryNotFound
+	case errors.Is(err, os.ErrPermission):
+		return ExitCodePermission, CategoryPermission
+	case errors.Is(err, context.DeadlineExceeded):
+		return ExitCodeTimeout, CategoryTimeout
+	case errors.Is(err, context.Canceled):
+		return ExitCodeInterrupted, CategoryInterrupted
+	default:
+		return ExitCodeError, CategoryError
+	}
+}
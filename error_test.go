@@ -2,6 +2,7 @@ package cliutil_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -60,3 +61,59 @@ func TestWriteErrorNoPrefix(t *testing.T) {
 		t.Errorf("have %q, want %q", have, want)
 	}
 }
+
+func TestExitCode(t *testing.T) {
+	reasons := errors.New("because reasons")
+
+	tests := []struct {
+		err      error
+		code     int
+		category string
+	}{
+		{nil, cliutil.ExitCodeOK, ""},
+		{reasons, cliutil.ExitCodeError, cliutil.CategoryError},
+		{cliutil.NewUsageError(reasons), cliutil.ExitCodeUsage, cliutil.CategoryUsage},
+		{cliutil.NewConfigError(reasons), cliutil.ExitCodeConfig, cliutil.CategoryConfig},
+		{cliutil.NewNotFoundError(reasons), cliutil.ExitCodeNotFound, cliutil.CategoryNotFound},
+		{cliutil.NewPermissionError(reasons), cliutil.ExitCodePermission, cliutil.CategoryPermission},
+		{cliutil.NewTimeoutError(reasons), cliutil.ExitCodeTimeout, cliutil.CategoryTimeout},
+		{cliutil.NewInterruptedError(reasons), cliutil.ExitCodeInterrupted, cliutil.CategoryInterrupted},
+		{fmt.Errorf("wrapped: %w", cliutil.NewConfigError(reasons)), cliutil.ExitCodeConfig, cliutil.CategoryConfig},
+		{cliutil.NewExitError(3, "custom", reasons), 3, "custom"},
+		{cliutil.OptionErrors{{Option: "token", Err: cliutil.ErrRequired}}, cliutil.ExitCodeUsage, cliutil.CategoryUsage},
+		{&os.PathError{Op: "open", Path: "/missing", Err: os.ErrNotExist}, cliutil.ExitCodeNotFound, cliutil.CategoryNotFound},
+		{fmt.Errorf("error opening file: %w", os.ErrPermission), cliutil.ExitCodePermission, cliutil.CategoryPermission},
+		{context.DeadlineExceeded, cliutil.ExitCodeTimeout, cliutil.CategoryTimeout},
+		{context.Canceled, cliutil.ExitCodeInterrupted, cliutil.CategoryInterrupted},
+	}
+
+	for _, tt := range tests {
+		if have := cliutil.ExitCode(tt.err); have != tt.code {
+			t.Errorf("%v: have %v, want %v", tt.err, have, tt.code)
+		}
+		if have := cliutil.ErrorCategory(tt.err); have != tt.category {
+			t.Errorf("%v: have %q, want %q", tt.err, have, tt.category)
+		}
+	}
+}
+
+func TestNewExitErrorNil(t *testing.T) {
+	if err := cliutil.NewUsageError(nil); err != nil {
+		t.Errorf("have %v, want nil", err)
+	}
+}
+
+// See https://talks.golang.org/2014/testing.slide#23
+func TestHandleErrorExitCode(t *testing.T) {
+	if os.Getenv("CLIUTIL_TEST_HANDLE_ERROR_EXIT_CODE") == "1" {
+		cliutil.HandleError(testCmd, cliutil.NewNotFoundError(errors.New("because reasons")))
+		return
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=TestHandleErrorExitCode")
+	cmd.Env = append(os.Environ(), "CLIUTIL_TEST_HANDLE_ERROR_EXIT_CODE=1")
+	err := cmd.Run()
+	if e, ok := err.(*exec.ExitError); ok && e.ExitCode() == cliutil.ExitCodeNotFound {
+		return
+	}
+	t.Fatalf("process ran with err %v, want exit status %v", err, cliutil.ExitCodeNotFound)
+}
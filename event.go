@@ -1,50 +1,263 @@
 package cliutil
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 )
 
-// EventListener listens for SIGINT and SIGTERM signals and notifies the
-// shutdown channel if it detects that either was sent.
+// DefaultShutdownTimeout is the default time shutdown hooks have to finish.
+const DefaultShutdownTimeout = 10 * time.Second
+
+// ShutdownHook is a function that is run when the EventListener shuts down.
+// Hooks should return once ctx is done.
+type ShutdownHook func(ctx context.Context) error
+
+// EventListener listens for SIGINT and SIGTERM signals and shuts down if it
+// detects that either was sent. Shutting down cancels the listener's context
+// and runs the registered shutdown hooks. A second signal forces the process
+// to exit. SIGHUP signals run the registered reload hooks.
 type EventListener struct {
 	signal   chan os.Signal
+	stop     chan struct{}
 	shutdown chan bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// reloadMu serializes the reload hooks run for each SIGHUP.
+	reloadMu sync.Mutex
+
+	mu      sync.Mutex
+	hooks   []ShutdownHook
+	reloads []func()
+	timeout time.Duration
+	err     error
+	stopped bool
+
+	shutdownOnce sync.Once
+	stopOnce     sync.Once
+	exit         func(int)
 }
 
 // NewEventListener returns an EventListener with the channels initialized.
 func NewEventListener() *EventListener {
+	return NewEventListenerWithContext(context.Background())
+}
+
+// NewEventListenerWithContext returns an EventListener whose context is
+// derived from ctx.
+func NewEventListenerWithContext(ctx context.Context) *EventListener {
+	ctx, cancel := context.WithCancel(ctx)
 	return &EventListener{
-		signal:   make(chan os.Signal),
+		signal:   make(chan os.Signal, 2),
+		stop:     make(chan struct{}),
 		shutdown: make(chan bool),
+		ctx:      ctx,
+		cancel:   cancel,
+		timeout:  DefaultShutdownTimeout,
+		exit:     os.Exit,
 	}
 }
 
-// Run runs the event listener in a goroutine and sends e message to
-// EventListener.shutdown if a SIGINT or SIGTERM signal is detected.
+// Run runs the event listener in a goroutine and shuts down if a SIGINT or
+// SIGTERM signal is detected. The process exits with ExitCodeInterrupted if
+// another signal is detected while shutting down.
 func (e *EventListener) Run() *EventListener {
-	signal.Notify(e.signal, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	e.mu.Lock()
+	if !e.stopped {
+		signal.Notify(e.signal, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	}
+	e.mu.Unlock()
 
 	go func() {
+		var received bool
 		for {
+			var sig os.Signal
 			select {
-			case <-e.signal:
-				e.shutdown <- true
-				break
+			case sig = <-e.signal:
+			case <-e.stop:
+				return
+			}
+
+			if sig == syscall.SIGHUP {
+				go e.reload()
+				continue
+			}
+			if received {
+				e.exit(ExitCodeInterrupted)
+				return
 			}
+			received = true
+			go e.Shutdown()
 		}
 	}()
 
 	return e
 }
 
-// Wait waits for EventListener.shutdown to receive a message.
+// Context returns a context that is cancelled when the listener shuts down.
+// Pass it to cobra.Command.ExecuteContext so that commands can access it via
+// cobra.Command.Context.
+func (e *EventListener) Context() context.Context {
+	return e.ctx
+}
+
+// OnShutdown registers a hook that is run when the listener shuts down.
+// Hooks are run in the reverse order they were registered.
+func (e *EventListener) OnShutdown(hook ShutdownHook) *EventListener {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.hooks = append(e.hooks, hook)
+	return e
+}
+
+// OnReload registers a hook that is run when a SIGHUP signal is detected,
+// e.g., a function that calls Reloader.Reload. SIGHUP is only handled by the
+// listener once a hook is registered and until StopSignal is called.
+func (e *EventListener) OnReload(hook func()) *EventListener {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.reloads) == 0 && !e.stopped {
+		signal.Notify(e.signal, syscall.SIGHUP)
+	}
+	e.reloads = append(e.reloads, hook)
+	return e
+}
+
+// reload runs the reload hooks in the order they were registered. Reloads
+// triggered by successive signals run one after another.
+func (e *EventListener) reload() {
+	e.reloadMu.Lock()
+	defer e.reloadMu.Unlock()
+
+	e.mu.Lock()
+	hooks := make([]func(), len(e.reloads))
+	copy(hooks, e.reloads)
+	e.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook()
+	}
+}
+
+// SetShutdownTimeout sets how long the shutdown hooks have to finish.
+func (e *EventListener) SetShutdownTimeout(d time.Duration) *EventListener {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.timeout = d
+	return e
+}
+
+// Shutdown cancels the listener's context and runs the shutdown hooks. It is
+// called when a signal is detected, but it can also be called directly. Only
+// the first call runs the hooks, and every call returns their result.
+func (e *EventListener) Shutdown() error {
+	e.shutdownOnce.Do(func() {
+		e.cancel()
+
+		e.mu.Lock()
+		hooks := make([]ShutdownHook, len(e.hooks))
+		copy(hooks, e.hooks)
+		timeout := e.timeout
+		e.mu.Unlock()
+
+		err := runShutdownHooks(hooks, timeout)
+
+		e.mu.Lock()
+		e.err = err
+		e.mu.Unlock()
+
+		close(e.shutdown)
+	})
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.err
+}
+
+// ShutdownErrors aggregates the errors returned by shutdown hooks. The errors
+// can be inspected via errors.Is and errors.As.
+type ShutdownErrors []error
+
+// Error implements error.Error.
+func (e ShutdownErrors) Error() string {
+	msgs := make([]string, len(e))
+	for idx, err := range e {
+		msgs[idx] = err.Error()
+	}
+	return "error shutting down: " + strings.Join(msgs, "; ")
+}
+
+// Is returns true if any of the hook errors matches target.
+func (e ShutdownErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first hook error that matches target.
+func (e ShutdownErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// runShutdownHooks runs hooks in reverse order and returns an error if any
+// hook failed or if they didn't finish within timeout. Errors returned by the
+// hooks are aggregated in ShutdownErrors.
+func runShutdownHooks(hooks []ShutdownHook, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan ShutdownErrors, 1)
+	go func() {
+		var errs ShutdownErrors
+		for idx := len(hooks) - 1; idx >= 0; idx-- {
+			if err := hooks[idx](ctx); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		done <- errs
+	}()
+
+	select {
+	case errs := <-done:
+		if len(errs) > 0 {
+			return errs
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("error shutting down: %w", ctx.Err())
+	}
+}
+
+// Wait waits for the listener to shut down and the shutdown hooks to finish.
 func (e *EventListener) Wait() {
 	<-e.shutdown
 }
 
-// StopSignal stops relaying incoming signals to EventListener.signal.
+// StopSignal stops relaying incoming signals to EventListener.signal and
+// stops the goroutine started by Run. Signals are not relayed again, even if
+// reload hooks are registered afterwards.
 func (e *EventListener) StopSignal() {
-	signal.Stop(e.signal)
+	e.stopOnce.Do(func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		e.stopped = true
+		signal.Stop(e.signal)
+		close(e.stop)
+	})
 }
@@ -5,7 +5,12 @@ package cliutil_test
 import (
 	"fmt"
 	"os"
+	"os/signal"
 	"syscall"
+	"testing"
+	"time"
+
+	"github.com/cpliakas/cliutil"
 )
 
 func sendCtrlBreak() error {
@@ -21,3 +26,29 @@ func sendCtrlBreak() error {
 
 	return nil
 }
+
+func TestEventListenerStopSignal(t *testing.T) {
+	// Keep SIGHUP from terminating the test process.
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	defer signal.Stop(ch)
+
+	e := cliutil.NewEventListener().Run()
+	e.StopSignal()
+	e.StopSignal()
+
+	// Reload hooks registered after stopping are not run.
+	reloaded := make(chan bool, 1)
+	e.OnReload(func() { reloaded <- true })
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	<-ch
+
+	select {
+	case <-reloaded:
+		t.Error("expected reload hook not to run")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
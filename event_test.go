@@ -1,10 +1,16 @@
 package cliutil_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
 	"testing"
 	"time"
 
 	"github.com/cpliakas/cliutil"
+	"github.com/go-test/deep"
 )
 
 func TestHandleEventListener(t *testing.T) {
@@ -41,6 +47,110 @@ func TestHandleEventListener(t *testing.T) {
 	}
 }
 
+func TestEventListenerShutdown(t *testing.T) {
+	var order []int
+	e := cliutil.NewEventListener()
+	for i := 1; i <= 3; i++ {
+		i := i
+		e.OnShutdown(func(ctx context.Context) error {
+			order = append(order, i)
+			return nil
+		})
+	}
+
+	if err := e.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	e.Wait()
+
+	if diff := deep.Equal(order, []int{3, 2, 1}); diff != nil {
+		t.Error(diff)
+	}
+
+	if err := e.Context().Err(); err != context.Canceled {
+		t.Errorf("got %v, expected %v", err, context.Canceled)
+	}
+
+	// Subsequent calls don't run the hooks again.
+	if err := e.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != 3 {
+		t.Errorf("got %v hook calls, expected 3", len(order))
+	}
+}
+
+func TestEventListenerShutdownError(t *testing.T) {
+	e := cliutil.NewEventListener()
+	e.OnShutdown(func(ctx context.Context) error { return errors.New("because reasons") })
+
+	ex := "error shutting down: because reasons"
+	if err := e.Shutdown(); err == nil || err.Error() != ex {
+		t.Errorf("got %v, expected %q", err, ex)
+	}
+}
+
+type shutdownHookError struct{ hook string }
+
+func (e *shutdownHookError) Error() string { return e.hook + " failed" }
+
+func TestEventListenerShutdownErrors(t *testing.T) {
+	errClosed := errors.New("already closed")
+
+	e := cliutil.NewEventListener()
+	e.OnShutdown(func(ctx context.Context) error { return &shutdownHookError{"db"} })
+	e.OnShutdown(func(ctx context.Context) error { return fmt.Errorf("server: %w", errClosed) })
+
+	err := e.Shutdown()
+	if ex := "error shutting down: server: already closed; db failed"; err == nil || err.Error() != ex {
+		t.Errorf("got %v, expected %q", err, ex)
+	}
+	if !errors.Is(err, errClosed) {
+		t.Errorf("got %v, expected %v", err, errClosed)
+	}
+
+	var hookErr *shutdownHookError
+	if !errors.As(err, &hookErr) || hookErr.hook != "db" {
+		t.Errorf("got %v, expected *shutdownHookError", err)
+	}
+}
+
+func TestEventListenerShutdownTimeout(t *testing.T) {
+	e := cliutil.NewEventListener().SetShutdownTimeout(10 * time.Millisecond)
+	e.OnShutdown(func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	if err := e.Shutdown(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, expected %v", err, context.DeadlineExceeded)
+	}
+}
+
 func interrupt() {
 
 }
+
+// See https://talks.golang.org/2014/testing.slide#23
+func TestEventListenerForceExit(t *testing.T) {
+	if os.Getenv("CLIUTIL_TEST_FORCE_EXIT") == "1" {
+		e := cliutil.NewEventListener().Run()
+		e.OnShutdown(func(ctx context.Context) error {
+			time.Sleep(time.Minute)
+			return nil
+		})
+		time.Sleep(100 * time.Millisecond)
+		sendCtrlBreak()
+		time.Sleep(100 * time.Millisecond)
+		sendCtrlBreak()
+		time.Sleep(time.Minute)
+		return
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=TestEventListenerForceExit")
+	cmd.Env = append(os.Environ(), "CLIUTIL_TEST_FORCE_EXIT=1")
+	err := cmd.Run()
+	if e, ok := err.(*exec.ExitError); ok && e.ExitCode() == cliutil.ExitCodeInterrupted {
+		return
+	}
+	t.Fatalf("process ran with err %v, want exit status %v", err, cliutil.ExitCodeInterrupted)
+}
@@ -2,8 +2,10 @@ package cliutil
 
 import (
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -12,11 +14,60 @@ import (
 func InitConfig(envPrefix string) (c *viper.Viper) {
 	c = viper.New()
 	c.SetEnvPrefix(envPrefix)
-	c.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	c.SetEnvKeyReplacer(envKeyReplacer)
 	c.AutomaticEnv()
+
+	cfgmetaMu.Lock()
+	defer cfgmetaMu.Unlock()
+	meta := getConfigMeta(c)
+	meta.envPrefix = envPrefix
+	meta.hasEnv = true
+
 	return
 }
 
+// envKeyReplacer maps option names to environment variable names.
+var envKeyReplacer = strings.NewReplacer("-", "_")
+
+// EnvVar returns the name of the environment variable that sets the named
+// option. An empty string is returned if cfg was not initialized by
+// InitConfig and the name wasn't set via Flagger.BindEnv, because environment
+// variables are not read in that case.
+func EnvVar(cfg *viper.Viper, name string) string {
+	if names := EnvVars(cfg, name); len(names) > 0 {
+		return names[0]
+	}
+	return ""
+}
+
+// EnvVars returns the names of the environment variables that set the named
+// option in order of precedence, i.e., the name derived from the option if
+// cfg was initialized by InitConfig, which viper reads first, followed by the
+// names set via Flagger.BindEnv.
+func EnvVars(cfg *viper.Viper, name string) []string {
+	cfgmetaMu.RLock()
+	defer cfgmetaMu.RUnlock()
+	meta, ok := lookupConfigMeta(cfg)
+	if !ok {
+		return nil
+	}
+
+	var names []string
+	if meta.hasEnv {
+		env := envKeyReplacer.Replace(name)
+		if meta.envPrefix != "" {
+			env = meta.envPrefix + "_" + env
+		}
+		names = append(names, strings.ToUpper(env))
+	}
+	for _, env := range meta.envs[name] {
+		if len(names) == 0 || env != names[0] {
+			names = append(names, env)
+		}
+	}
+	return names
+}
+
 // AddCommand adds a comand to it's parent, initializes the configuration,
 // and returns a flagger to easily add options.
 func AddCommand(parentCmd, cmd *cobra.Command, envPrefix string) (*viper.Viper, *Flagger) {
@@ -29,72 +80,301 @@ func AddCommand(parentCmd, cmd *cobra.Command, envPrefix string) (*viper.Viper,
 type Flagger struct {
 	cmd *cobra.Command
 	cfg *viper.Viper
+
+	// persistent makes the methods for local flags add persistent flags.
+	persistent bool
 }
 
 // NewFlagger returns a new Flagger with the *cobra.Command and *viper.Viper
 // set as properties.
 func NewFlagger(cmd *cobra.Command, cfg *viper.Viper) *Flagger {
+	cfgmetaMu.Lock()
+	defer cfgmetaMu.Unlock()
+	setCommandConfig(cmd, cfg)
+
 	return &Flagger{cmd: cmd, cfg: cfg}
 }
 
+// persistentFlagger returns a copy of the Flagger whose methods for local
+// flags add persistent flags, e.g., for options with the persistent tag key.
+func (f *Flagger) persistentFlagger() *Flagger {
+	return &Flagger{cmd: f.cmd, cfg: f.cfg, persistent: true}
+}
+
+// flags returns the flag set that the methods for local flags add flags to.
+func (f *Flagger) flags() *pflag.FlagSet {
+	if f.persistent {
+		return f.cmd.PersistentFlags()
+	}
+	return f.cmd.Flags()
+}
+
+// lookupFlag returns the local or persistent flag with the given name.
+func (f *Flagger) lookupFlag(name string) *pflag.Flag {
+	if flag := f.cmd.Flags().Lookup(name); flag != nil {
+		return flag
+	}
+	return f.cmd.PersistentFlags().Lookup(name)
+}
+
+// bindInheritedFlags binds the persistent flags that the command cfg was
+// passed to NewFlagger with inherits from its parents, so that options set
+// on a parent command can be read from the subcommand's configuration.
+func bindInheritedFlags(cfg *viper.Viper) {
+	cfgmetaMu.RLock()
+	meta, ok := lookupConfigMeta(cfg)
+	cfgmetaMu.RUnlock()
+	if !ok {
+		return
+	}
+	cmd := meta.cmd.Value()
+	if cmd == nil {
+		return
+	}
+
+	cmd.InheritedFlags().VisitAll(func(flag *pflag.Flag) {
+		cfg.BindPFlag(flag.Name, flag)
+	})
+}
+
 // Bool adds a local flag that accepts a boolean.
 func (f *Flagger) Bool(name, shorthand string, value bool, usage string) {
-	f.cmd.Flags().BoolP(name, shorthand, value, usage)
-	f.cfg.BindPFlag(name, f.cmd.Flags().Lookup(name))
+	f.flags().BoolP(name, shorthand, value, usage)
+	f.bindFlag(f.flags().Lookup(name))
 }
 
 // PersistentBool adds a persistent flag that accepts a boolean.
 func (f *Flagger) PersistentBool(name, shorthand string, value bool, usage string) {
 	f.cmd.PersistentFlags().BoolP(name, shorthand, value, usage)
-	f.cfg.BindPFlag(name, f.cmd.PersistentFlags().Lookup(name))
+	f.bindFlag(f.cmd.PersistentFlags().Lookup(name))
+}
+
+// BoolSlice adds a local flag that accepts a boolean slice.
+func (f *Flagger) BoolSlice(name, shorthand string, value []bool, usage string) {
+	f.flags().BoolSliceP(name, shorthand, value, usage)
+	f.bindFlag(f.flags().Lookup(name))
+}
+
+// PersistentBoolSlice adds a persistent flag that accepts a boolean slice.
+func (f *Flagger) PersistentBoolSlice(name, shorthand string, value []bool, usage string) {
+	f.cmd.PersistentFlags().BoolSliceP(name, shorthand, value, usage)
+	f.bindFlag(f.cmd.PersistentFlags().Lookup(name))
+}
+
+// Duration adds a local flag that accepts a duration.
+func (f *Flagger) Duration(name, shorthand string, value time.Duration, usage string) {
+	f.flags().DurationP(name, shorthand, value, usage)
+	f.bindFlag(f.flags().Lookup(name))
+}
+
+// PersistentDuration adds a persistent flag that accepts a duration.
+func (f *Flagger) PersistentDuration(name, shorthand string, value time.Duration, usage string) {
+	f.cmd.PersistentFlags().DurationP(name, shorthand, value, usage)
+	f.bindFlag(f.cmd.PersistentFlags().Lookup(name))
+}
+
+// Float32 adds a local flag that accepts a 32-bit float.
+func (f *Flagger) Float32(name, shorthand string, value float32, usage string) {
+	f.flags().Float32P(name, shorthand, value, usage)
+	f.bindFlag(f.flags().Lookup(name))
+}
+
+// PersistentFloat32 adds a persistent flag that accepts a 32-bit float.
+func (f *Flagger) PersistentFloat32(name, shorthand string, value float32, usage string) {
+	f.cmd.PersistentFlags().Float32P(name, shorthand, value, usage)
+	f.bindFlag(f.cmd.PersistentFlags().Lookup(name))
 }
 
 // Float64 adds a local flag that accepts a 64-bit float.
 func (f *Flagger) Float64(name, shorthand string, value float64, usage string) {
-	f.cmd.Flags().Float64P(name, shorthand, value, usage)
-	f.cfg.BindPFlag(name, f.cmd.Flags().Lookup(name))
+	f.flags().Float64P(name, shorthand, value, usage)
+	f.bindFlag(f.flags().Lookup(name))
 }
 
 // PersistentFloat64 adds a persistent flag that accepts a 64-bit float.
 func (f *Flagger) PersistentFloat64(name, shorthand string, value float64, usage string) {
 	f.cmd.PersistentFlags().Float64P(name, shorthand, value, usage)
-	f.cfg.BindPFlag(name, f.cmd.PersistentFlags().Lookup(name))
+	f.bindFlag(f.cmd.PersistentFlags().Lookup(name))
+}
+
+// Float64Slice adds a local flag that accepts a 64-bit float slice.
+func (f *Flagger) Float64Slice(name, shorthand string, value []float64, usage string) {
+	f.flags().Float64SliceP(name, shorthand, value, usage)
+	f.bindFlag(f.flags().Lookup(name))
+}
+
+// PersistentFloat64Slice adds a persistent flag that accepts a 64-bit float slice.
+func (f *Flagger) PersistentFloat64Slice(name, shorthand string, value []float64, usage string) {
+	f.cmd.PersistentFlags().Float64SliceP(name, shorthand, value, usage)
+	f.bindFlag(f.cmd.PersistentFlags().Lookup(name))
 }
 
 // Int adds a local flag that accepts an integer.
 func (f *Flagger) Int(name, shorthand string, value int, usage string) {
-	f.cmd.Flags().IntP(name, shorthand, value, usage)
-	f.cfg.BindPFlag(name, f.cmd.Flags().Lookup(name))
+	f.flags().IntP(name, shorthand, value, usage)
+	f.bindFlag(f.flags().Lookup(name))
 }
 
 // PersistentInt adds a persistent flag that accepts an integer.
 func (f *Flagger) PersistentInt(name, shorthand string, value int, usage string) {
 	f.cmd.PersistentFlags().IntP(name, shorthand, value, usage)
-	f.cfg.BindPFlag(name, f.cmd.PersistentFlags().Lookup(name))
+	f.bindFlag(f.cmd.PersistentFlags().Lookup(name))
+}
+
+// Int8 adds a local flag that accepts an 8-bit integer.
+func (f *Flagger) Int8(name, shorthand string, value int8, usage string) {
+	f.flags().Int8P(name, shorthand, value, usage)
+	f.bindFlag(f.flags().Lookup(name))
+}
+
+// PersistentInt8 adds a persistent flag that accepts an 8-bit integer.
+func (f *Flagger) PersistentInt8(name, shorthand string, value int8, usage string) {
+	f.cmd.PersistentFlags().Int8P(name, shorthand, value, usage)
+	f.bindFlag(f.cmd.PersistentFlags().Lookup(name))
+}
+
+// Int16 adds a local flag that accepts a 16-bit integer.
+func (f *Flagger) Int16(name, shorthand string, value int16, usage string) {
+	f.flags().Int16P(name, shorthand, value, usage)
+	f.bindFlag(f.flags().Lookup(name))
+}
+
+// PersistentInt16 adds a persistent flag that accepts a 16-bit integer.
+func (f *Flagger) PersistentInt16(name, shorthand string, value int16, usage string) {
+	f.cmd.PersistentFlags().Int16P(name, shorthand, value, usage)
+	f.bindFlag(f.cmd.PersistentFlags().Lookup(name))
+}
+
+// Int32 adds a local flag that accepts a 32-bit integer.
+func (f *Flagger) Int32(name, shorthand string, value int32, usage string) {
+	f.flags().Int32P(name, shorthand, value, usage)
+	f.bindFlag(f.flags().Lookup(name))
+}
+
+// PersistentInt32 adds a persistent flag that accepts a 32-bit integer.
+func (f *Flagger) PersistentInt32(name, shorthand string, value int32, usage string) {
+	f.cmd.PersistentFlags().Int32P(name, shorthand, value, usage)
+	f.bindFlag(f.cmd.PersistentFlags().Lookup(name))
+}
+
+// Int64 adds a local flag that accepts a 64-bit integer.
+func (f *Flagger) Int64(name, shorthand string, value int64, usage string) {
+	f.flags().Int64P(name, shorthand, value, usage)
+	f.bindFlag(f.flags().Lookup(name))
+}
+
+// PersistentInt64 adds a persistent flag that accepts a 64-bit integer.
+func (f *Flagger) PersistentInt64(name, shorthand string, value int64, usage string) {
+	f.cmd.PersistentFlags().Int64P(name, shorthand, value, usage)
+	f.bindFlag(f.cmd.PersistentFlags().Lookup(name))
 }
 
 // IntSlice adds a local flag that accepts an integer slice.
 func (f *Flagger) IntSlice(name, shorthand string, value []int, usage string) {
-	f.cmd.Flags().IntSliceP(name, shorthand, value, usage)
-	f.cfg.BindPFlag(name, f.cmd.Flags().Lookup(name))
+	f.flags().IntSliceP(name, shorthand, value, usage)
+	f.bindFlag(f.flags().Lookup(name))
 }
 
 // PersistentIntSlice adds a persistent flag that accepts an integer slice.
 func (f *Flagger) PersistentIntSlice(name, shorthand string, value []int, usage string) {
 	f.cmd.PersistentFlags().IntSliceP(name, shorthand, value, usage)
-	f.cfg.BindPFlag(name, f.cmd.PersistentFlags().Lookup(name))
+	f.bindFlag(f.cmd.PersistentFlags().Lookup(name))
 }
 
 // String adds a local flag that accepts an string.
 func (f *Flagger) String(name, shorthand, value, usage string) {
-	f.cmd.Flags().StringP(name, shorthand, value, usage)
-	f.cfg.BindPFlag(name, f.cmd.Flags().Lookup(name))
+	f.flags().StringP(name, shorthand, value, usage)
+	f.bindFlag(f.flags().Lookup(name))
 }
 
 // PersistentString adds a persistent flag that accepts an string.
 func (f *Flagger) PersistentString(name, shorthand, value, usage string) {
 	f.cmd.PersistentFlags().StringP(name, shorthand, value, usage)
-	f.cfg.BindPFlag(name, f.cmd.PersistentFlags().Lookup(name))
+	f.bindFlag(f.cmd.PersistentFlags().Lookup(name))
+}
+
+// StringSlice adds a local flag that accepts a string slice.
+func (f *Flagger) StringSlice(name, shorthand string, value []string, usage string) {
+	f.flags().StringSliceP(name, shorthand, value, usage)
+	f.bindFlag(f.flags().Lookup(name))
+}
+
+// PersistentStringSlice adds a persistent flag that accepts a string slice.
+func (f *Flagger) PersistentStringSlice(name, shorthand string, value []string, usage string) {
+	f.cmd.PersistentFlags().StringSliceP(name, shorthand, value, usage)
+	f.bindFlag(f.cmd.PersistentFlags().Lookup(name))
+}
+
+// Uint adds a local flag that accepts an unsigned integer.
+func (f *Flagger) Uint(name, shorthand string, value uint, usage string) {
+	f.flags().UintP(name, shorthand, value, usage)
+	f.bindFlag(f.flags().Lookup(name))
+}
+
+// PersistentUint adds a persistent flag that accepts an unsigned integer.
+func (f *Flagger) PersistentUint(name, shorthand string, value uint, usage string) {
+	f.cmd.PersistentFlags().UintP(name, shorthand, value, usage)
+	f.bindFlag(f.cmd.PersistentFlags().Lookup(name))
+}
+
+// Uint8 adds a local flag that accepts an 8-bit unsigned integer.
+func (f *Flagger) Uint8(name, shorthand string, value uint8, usage string) {
+	f.flags().Uint8P(name, shorthand, value, usage)
+	f.bindFlag(f.flags().Lookup(name))
+}
+
+// PersistentUint8 adds a persistent flag that accepts an 8-bit unsigned integer.
+func (f *Flagger) PersistentUint8(name, shorthand string, value uint8, usage string) {
+	f.cmd.PersistentFlags().Uint8P(name, shorthand, value, usage)
+	f.bindFlag(f.cmd.PersistentFlags().Lookup(name))
+}
+
+// Uint16 adds a local flag that accepts a 16-bit unsigned integer.
+func (f *Flagger) Uint16(name, shorthand string, value uint16, usage string) {
+	f.flags().Uint16P(name, shorthand, value, usage)
+	f.bindFlag(f.flags().Lookup(name))
+}
+
+// PersistentUint16 adds a persistent flag that accepts a 16-bit unsigned integer.
+func (f *Flagger) PersistentUint16(name, shorthand string, value uint16, usage string) {
+	f.cmd.PersistentFlags().Uint16P(name, shorthand, value, usage)
+	f.bindFlag(f.cmd.PersistentFlags().Lookup(name))
+}
+
+// Uint32 adds a local flag that accepts a 32-bit unsigned integer.
+func (f *Flagger) Uint32(name, shorthand string, value uint32, usage string) {
+	f.flags().Uint32P(name, shorthand, value, usage)
+	f.bindFlag(f.flags().Lookup(name))
+}
+
+// PersistentUint32 adds a persistent flag that accepts a 32-bit unsigned integer.
+func (f *Flagger) PersistentUint32(name, shorthand string, value uint32, usage string) {
+	f.cmd.PersistentFlags().Uint32P(name, shorthand, value, usage)
+	f.bindFlag(f.cmd.PersistentFlags().Lookup(name))
+}
+
+// Uint64 adds a local flag that accepts a 64-bit unsigned integer.
+func (f *Flagger) Uint64(name, shorthand string, value uint64, usage string) {
+	f.flags().Uint64P(name, shorthand, value, usage)
+	f.bindFlag(f.flags().Lookup(name))
+}
+
+// PersistentUint64 adds a persistent flag that accepts a 64-bit unsigned integer.
+func (f *Flagger) PersistentUint64(name, shorthand string, value uint64, usage string) {
+	f.cmd.PersistentFlags().Uint64P(name, shorthand, value, usage)
+	f.bindFlag(f.cmd.PersistentFlags().Lookup(name))
+}
+
+// Var adds a local flag whose value is a pflag.Value.
+func (f *Flagger) Var(value pflag.Value, name, shorthand, usage string) {
+	f.flags().VarP(value, name, shorthand, usage)
+	f.bindFlag(f.flags().Lookup(name))
+}
+
+// PersistentVar adds a persistent flag whose value is a pflag.Value.
+func (f *Flagger) PersistentVar(value pflag.Value, name, shorthand, usage string) {
+	f.cmd.PersistentFlags().VarP(value, name, shorthand, usage)
+	f.bindFlag(f.cmd.PersistentFlags().Lookup(name))
 }
 
 //
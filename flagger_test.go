@@ -1 +1,52 @@
 package cliutil_test
+
+import (
+	"testing"
+
+	"github.com/cpliakas/cliutil"
+	"github.com/spf13/cobra"
+)
+
+type PersistentInput struct {
+	Region string `cliutil:"option=region persistent default=us"`
+}
+
+type PersistentSubInput struct {
+	Name string `cliutil:"option=name"`
+}
+
+func TestSetOptionsPersistent(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "root"}
+	rootCfg := cliutil.InitConfig("CLIUTIL_TEST")
+	if err := cliutil.NewFlagger(rootCmd, rootCfg).SetOptions(&PersistentInput{}); err != nil {
+		t.Fatal(err)
+	}
+
+	global, sub := &PersistentInput{}, &PersistentSubInput{}
+	subCmd := &cobra.Command{Use: "sub"}
+	subCfg, flags := cliutil.AddCommand(rootCmd, subCmd, "CLIUTIL_TEST")
+	if err := flags.SetOptions(sub); err != nil {
+		t.Fatal(err)
+	}
+	subCmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := cliutil.ReadOptions(global, subCfg); err != nil {
+			return err
+		}
+		return cliutil.ReadOptions(sub, subCfg)
+	}
+
+	if rootCmd.PersistentFlags().Lookup("region") == nil {
+		t.Fatal("expected --region to be a persistent flag")
+	}
+
+	rootCmd.SetArgs([]string{"sub", "--region", "eu", "--name", "test"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if ex := "eu"; global.Region != ex {
+		t.Errorf("region: got %q, expected %q", global.Region, ex)
+	}
+	if ex := "test"; sub.Name != ex {
+		t.Errorf("name: got %q, expected %q", sub.Name, ex)
+	}
+}
@@ -0,0 +1,142 @@
+package cliutil
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Group* constants contain the kinds of flag groups, which are also the tag
+// keys that add options to groups, e.g., exclusive=source.
+const (
+	GroupExclusive   = "exclusive"
+	GroupTogether    = "together"
+	GroupOneRequired = "onerequired"
+)
+
+// ErrFlagGroup is returned when the options in a flag group violate the
+// group's constraint.
+var ErrFlagGroup = errors.New("flag group constraint not satisfied")
+
+// flagGroup is a set of options with a constraint on which of them are set.
+// Tagged groups are defined by struct tags and only apply to the options read
+// from the struct.
+type flagGroup struct {
+	kind    string
+	options []string
+	tagged  bool
+}
+
+// MarkFlagsMutuallyExclusive marks the named flags so that ReadOptions returns
+// an error if more than one of them is set.
+func (f *Flagger) MarkFlagsMutuallyExclusive(names ...string) {
+	f.addFlagGroup(GroupExclusive, names, false)
+}
+
+// MarkFlagsRequiredTogether marks the named flags so that ReadOptions returns
+// an error if some, but not all, of them are set.
+func (f *Flagger) MarkFlagsRequiredTogether(names ...string) {
+	f.addFlagGroup(GroupTogether, names, false)
+}
+
+// MarkFlagsOneRequired marks the named flags so that ReadOptions returns an
+// error if none of them are set.
+func (f *Flagger) MarkFlagsOneRequired(names ...string) {
+	f.addFlagGroup(GroupOneRequired, names, false)
+}
+
+// addFlagGroup registers the group with the Flagger's config and documents
+// it in the usage of the group's flags.
+func (f *Flagger) addFlagGroup(kind string, names []string, tagged bool) {
+	cfgmetaMu.Lock()
+	meta := getConfigMeta(f.cfg)
+	meta.groups = append(meta.groups, flagGroup{kind: kind, options: names, tagged: tagged})
+	cfgmetaMu.Unlock()
+
+	for _, name := range names {
+		if flag := f.lookupFlag(name); flag != nil {
+			flag.Usage = strings.TrimSpace(flag.Usage + " " + groupUsage(kind, name, names))
+		}
+	}
+}
+
+// groupUsage returns the note appended to the usage of a flag in a group.
+func groupUsage(kind, name string, names []string) string {
+	var others []string
+	for _, other := range names {
+		if other != name {
+			others = append(others, "--"+other)
+		}
+	}
+
+	switch kind {
+	case GroupExclusive:
+		return fmt.Sprintf("(cannot be used with %s)", strings.Join(others, ", "))
+	case GroupTogether:
+		return fmt.Sprintf("(must be used with %s)", strings.Join(others, ", "))
+	default:
+		return fmt.Sprintf("(or %s is required)", strings.Join(others, ", "))
+	}
+}
+
+// tagGroups adds the option described by tag to the groups named by its
+// group keys. Group names may be comma separated.
+func tagGroups(groups map[string][]string, tag map[string]string) {
+	for _, kind := range []string{GroupExclusive, GroupTogether, GroupOneRequired} {
+		s, ok := tag[kind]
+		if !ok {
+			continue
+		}
+		for _, name := range strings.Split(s, ",") {
+			key := kind + "=" + strings.TrimSpace(name)
+			groups[key] = append(groups[key], tag["option"])
+		}
+	}
+}
+
+// checkFlagGroups records an OptionError in errs for each group registered
+// with cfg whose constraint isn't satisfied. Groups defined by struct tags
+// are only checked for the options in read, whereas groups registered via the
+// Mark* methods are always checked. Options count as set regardless of
+// whether they were set via flag, environment variable or configuration.
+func checkFlagGroups(cfg *viper.Viper, read map[string]bool, errs *OptionErrors) {
+	cfgmetaMu.RLock()
+	var groups []flagGroup
+	if meta, ok := lookupConfigMeta(cfg); ok {
+		groups = meta.groups
+	}
+	cfgmetaMu.RUnlock()
+
+	for _, g := range groups {
+		var set, unset []string
+		for _, name := range g.options {
+			if g.tagged && !read[name] {
+				continue
+			}
+			if cfg.IsSet(name) {
+				set = append(set, name)
+			} else {
+				unset = append(unset, name)
+			}
+		}
+		if len(set)+len(unset) == 0 {
+			continue
+		}
+
+		switch {
+		case g.kind == GroupExclusive && len(set) > 1:
+			err := fmt.Errorf("%w: cannot be used with --%s", ErrFlagGroup, strings.Join(set[1:], ", --"))
+			*errs = append(*errs, newOptionError(cfg, set[0], err))
+		case g.kind == GroupTogether && len(set) > 0 && len(unset) > 0:
+			for _, name := range unset {
+				err := fmt.Errorf("%w: required when --%s is set", ErrFlagGroup, set[0])
+				*errs = append(*errs, newOptionError(cfg, name, err))
+			}
+		case g.kind == GroupOneRequired && len(set) == 0:
+			err := fmt.Errorf("%w: one of --%s is required", ErrFlagGroup, strings.Join(g.options, ", --"))
+			*errs = append(*errs, newOptionError(cfg, g.options[0], err))
+		}
+	}
+}
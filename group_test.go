@@ -0,0 +1,121 @@
+package cliutil_test
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cpliakas/cliutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type GroupInput struct {
+	File     string `cliutil:"option=file exclusive=source onerequired=source"`
+	URL      string `cliutil:"option=url exclusive=source onerequired=source"`
+	User     string `cliutil:"option=user together=auth"`
+	Password string `cliutil:"option=password together=auth"`
+}
+
+func TestReadOptionsFlagGroups(t *testing.T) {
+	tests := []struct {
+		set    map[string]string
+		errors []string
+	}{
+		{map[string]string{"file": "f"}, nil},
+		{map[string]string{"file": "f", "url": "u"}, []string{"file"}},
+		{map[string]string{}, []string{"file"}},
+		{map[string]string{"url": "u", "user": "me"}, []string{"password"}},
+		{map[string]string{"url": "u", "user": "me", "password": "secret"}, nil},
+	}
+
+	for _, tt := range tests {
+		v := cliutil.InitConfig("CLIUTIL_TEST")
+		newTestCommand(t, v, &GroupInput{})
+		for k, val := range tt.set {
+			v.Set(k, val)
+		}
+
+		err := cliutil.ReadOptions(&GroupInput{}, v)
+		if len(tt.errors) == 0 {
+			if err != nil {
+				t.Errorf("%v: unexpected error: %v", tt.set, err)
+			}
+			continue
+		}
+
+		var errs cliutil.OptionErrors
+		if !errors.As(err, &errs) || !errors.Is(err, cliutil.ErrFlagGroup) {
+			t.Errorf("%v: got %v, expected flag group errors", tt.set, err)
+			continue
+		}
+		var actual []string
+		for _, e := range errs {
+			actual = append(actual, e.Option)
+		}
+		if strings.Join(actual, ",") != strings.Join(tt.errors, ",") {
+			t.Errorf("%v: got errors for %v, expected %v", tt.set, actual, tt.errors)
+		}
+	}
+}
+
+func TestReadOptionsFlagGroupsEnv(t *testing.T) {
+	os.Setenv("CLIUTIL_TEST_FILE", "f")
+	defer os.Unsetenv("CLIUTIL_TEST_FILE")
+
+	v := cliutil.InitConfig("CLIUTIL_TEST")
+	newTestCommand(t, v, &GroupInput{})
+	v.Set("url", "u")
+
+	err := cliutil.ReadOptions(&GroupInput{}, v)
+	if code := cliutil.ExitCode(err); code != cliutil.ExitCodeUsage {
+		t.Errorf("got exit code %d (%v), expected %d", code, err, cliutil.ExitCodeUsage)
+	}
+}
+
+func TestSetOptionsFlagGroupsUsage(t *testing.T) {
+	cmd := newTestCommand(t, cliutil.InitConfig("CLIUTIL_TEST"), &GroupInput{})
+	usage := cmd.Flags().Lookup("file").Usage
+	for _, ex := range []string{"cannot be used with --url", "or --url is required"} {
+		if !strings.Contains(usage, ex) {
+			t.Errorf("got %q, expected it to contain %q", usage, ex)
+		}
+	}
+}
+
+func TestMarkFlagsMutuallyExclusive(t *testing.T) {
+	cmd := &cobra.Command{Use: "test"}
+	v := viper.New()
+	flags := cliutil.NewFlagger(cmd, v)
+	flags.String("json", "", "", "")
+	flags.String("yaml", "", "", "")
+	flags.MarkFlagsMutuallyExclusive("json", "yaml")
+
+	type Input struct {
+		JSON string `cliutil:"option=json"`
+		YAML string `cliutil:"option=yaml"`
+	}
+
+	v.Set("json", "a")
+	v.Set("yaml", "b")
+	if err := cliutil.ReadOptions(&Input{}, v); !errors.Is(err, cliutil.ErrFlagGroup) {
+		t.Errorf("got %v, expected %v", err, cliutil.ErrFlagGroup)
+	}
+}
+
+func TestMarkFlagsWithoutOptions(t *testing.T) {
+	cmd := &cobra.Command{Use: "test"}
+	v := viper.New()
+	flags := cliutil.NewFlagger(cmd, v)
+	flags.String("file", "", "", "")
+	flags.String("url", "", "", "")
+	flags.MarkFlagsMutuallyExclusive("file", "url")
+
+	if err := cmd.ParseFlags([]string{"--file", "f", "--url", "u"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cliutil.ReadOptions(&struct{}{}, v); !errors.Is(err, cliutil.ErrFlagGroup) {
+		t.Errorf("got %v, expected %v", err, cliutil.ErrFlagGroup)
+	}
+}
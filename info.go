@@ -0,0 +1,105 @@
+package cliutil
+
+import (
+	"reflect"
+	"sync"
+	"weak"
+
+	"github.com/spf13/cobra"
+)
+
+// OptionInfo describes an option set via Flagger.SetOptions, e.g., to
+// generate documentation.
+type OptionInfo struct {
+
+	// Name is the name of the flag, which is also the configuration key.
+	Name string
+
+	// Shorthand is the flag's one-letter abbreviation, if any.
+	Shorthand string
+
+	// Usage is the option's usage as set by the usage key.
+	Usage string
+
+	// Default is the option's default value. Defaults of secrets are
+	// redacted.
+	Default string
+
+	// Type is the Go type of the option's field.
+	Type string
+
+	// EnvVar is the environment variable that sets the option, if any.
+	EnvVar string
+
+	// Arg is the option's position if it is a positional argument, e.g., 0
+	// or rest.
+	Arg string
+
+	// Required, Secret and Persistent are set by the corresponding tag keys.
+	Required   bool
+	Secret     bool
+	Persistent bool
+
+	// Rules are the option's validation rules, e.g., min=1.
+	Rules []string
+
+	tag map[string]string
+}
+
+var (
+	cmdoptsMu sync.RWMutex
+	cmdopts   = make(map[weak.Pointer[cobra.Command]][]OptionInfo)
+)
+
+// CommandOptions returns the options set on cmd via Flagger.SetOptions in
+// the order they were set.
+func CommandOptions(cmd *cobra.Command) []OptionInfo {
+	cmdoptsMu.RLock()
+	defer cmdoptsMu.RUnlock()
+	opts := cmdopts[weak.Make(cmd)]
+	infos := make([]OptionInfo, len(opts))
+	copy(infos, opts)
+	return infos
+}
+
+// lookupOptionInfo returns the OptionInfo of the named option set on cmd.
+func lookupOptionInfo(cmd *cobra.Command, name string) (OptionInfo, bool) {
+	cmdoptsMu.RLock()
+	defer cmdoptsMu.RUnlock()
+	for _, info := range cmdopts[weak.Make(cmd)] {
+		if info.Name == name {
+			return info, true
+		}
+	}
+	return OptionInfo{}, false
+}
+
+// addOptionInfo records the option described by tag for a field of type t.
+func (f *Flagger) addOptionInfo(tag map[string]string, t reflect.Type) {
+	info := OptionInfo{
+		Name:       tag["option"],
+		Shorthand:  tag["short"],
+		Usage:      tag["usage"],
+		Default:    tag["default"],
+		Type:       t.String(),
+		EnvVar:     EnvVar(f.cfg, tag["option"]),
+		Arg:        tag["arg"],
+		Required:   tagBool(tag, "required"),
+		Secret:     tagBool(tag, "secret"),
+		Persistent: tagBool(tag, "persistent"),
+		tag:        tag,
+	}
+	if info.Secret && info.Default != "" {
+		info.Default = RedactedValue
+	}
+	for _, rule := range validationRules {
+		if param, ok := tag[rule.key]; ok {
+			info.Rules = append(info.Rules, rule.key+"="+param)
+		}
+	}
+
+	cmdoptsMu.Lock()
+	defer cmdoptsMu.Unlock()
+	key := weak.Make(f.cmd)
+	cmdopts[key] = append(cmdopts[key], info)
+}
@@ -1,12 +1,17 @@
 package cliutil
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/rs/xid"
 )
@@ -128,10 +133,15 @@ func (l *LeveledLogger) SetMessageWriter(fn MessageWriter) {
 	l.writer = fn
 }
 
-// Fatal writes an fatal level log and exits with a non-zero exit code.
+// Fatal writes an fatal level log and exits with a non-zero exit code. The
+// exit code is determined by ExitCode.
 func (l LeveledLogger) Fatal(ctx context.Context, message string, err error) {
 	l.printLog(ctx, l.level < LogLevelFatal, l.loggers[0], "FATAL", message, err)
-	os.Exit(1)
+	code := ExitCode(err)
+	if code == ExitCodeOK {
+		code = ExitCodeError
+	}
+	os.Exit(code)
 }
 
 // FatalIfError writes a fatal level log and exits with a non-zero exit code if
@@ -176,29 +186,46 @@ func (l *LeveledLogger) printLog(ctx context.Context, skip bool, logger *log.Log
 	}
 }
 
+// LogTag is a key/value pair that is attached to log messages via context.
+type LogTag struct {
+	Key   string
+	Value interface{}
+}
+
 // ContextWithLogTag returns a new context with log tags appended.
 func ContextWithLogTag(ctx context.Context, key string, val interface{}) context.Context {
 	if !IsLetters(key) {
 		panic(fmt.Errorf("key must only contain letters: %q passed", key))
 	}
 
-	s := fmt.Sprintf("%v", val)
-	var modifier string
-	if HasSpace(s) {
-		modifier = "%q"
-	} else {
-		modifier = "%s"
-	}
+	// Copy the tags so that contexts derived from the same parent don't share
+	// the underlying array.
+	parent := LogTags(ctx)
+	tags := make([]LogTag, len(parent), len(parent)+1)
+	copy(tags, parent)
+	tags = append(tags, LogTag{Key: key, Value: val})
 
-	format := key + "=" + modifier
-	tag := fmt.Sprintf(format, s)
+	return context.WithValue(ctx, CtxLogTags, tags)
+}
 
-	tags := ctx.Value(CtxLogTags)
-	if tags != nil {
-		tag = tags.(string) + " " + tag
-	}
+// LogTags returns the log tags stored in ctx in the order they were added.
+func LogTags(ctx context.Context) []LogTag {
+	tags, _ := ctx.Value(CtxLogTags).([]LogTag)
+	return tags
+}
 
-	return context.WithValue(ctx, CtxLogTags, tag)
+// formatLogTags formats tags as key=value pairs separated by spaces. Values
+// that contain spaces are quoted, and secrets are redacted.
+func formatLogTags(tags []LogTag) string {
+	parts := make([]string, len(tags))
+	for idx, tag := range tags {
+		s := fmt.Sprintf("%v", Redact(tag.Value))
+		if HasSpace(s) {
+			s = strconv.Quote(s)
+		}
+		parts[idx] = tag.Key + "=" + s
+	}
+	return strings.Join(parts, " ")
 }
 
 // DefaultMessageWriter formats log messages according to Splunk's best
@@ -222,16 +249,76 @@ func DefaultMessageWriter(ctx context.Context, logger *log.Logger, level string,
 	}
 
 	// Append the log tags if there are any.
-	tags := ctx.Value(CtxLogTags)
-	if tags != nil {
+	if tags := LogTags(ctx); len(tags) > 0 {
 		format = format + " %s"
-		args = append(args, tags)
+		args = append(args, formatLogTags(tags))
 	}
 
 	// Print the log message.
 	logger.Printf(format, args...)
 }
 
+// jsonWriterMu serializes the lines written by JSONMessageWriter, which writes
+// to the logger's writer directly.
+var jsonWriterMu sync.Mutex
+
+// jsonReservedKeys are the fields written by JSONMessageWriter that log tags
+// must not overwrite.
+var jsonReservedKeys = map[string]bool{"time": true, "level": true, "message": true, "error": true}
+
+// JSONMessageWriter formats log messages as one JSON object per line with the
+// time, level, message, error and each log tag as its own field. Log tags
+// named after one of these fields are prefixed with "tag_". The flags and
+// prefix of the logger are ignored so that every line is valid JSON. Secrets
+// in log tags are redacted.
+func JSONMessageWriter(ctx context.Context, logger *log.Logger, level string, message string, err error) {
+	var buf bytes.Buffer
+
+	buf.WriteString(`{"time":`)
+	writeJSONValue(&buf, time.Now().UTC().Format(time.RFC3339Nano))
+	buf.WriteString(`,"level":`)
+	writeJSONValue(&buf, level)
+	buf.WriteString(`,"message":`)
+	writeJSONValue(&buf, message)
+
+	// Append the error if there is one.
+	if err != nil {
+		buf.WriteString(`,"error":`)
+		writeJSONValue(&buf, err.Error())
+	}
+
+	// Append the log tags as typed fields.
+	for _, tag := range LogTags(ctx) {
+		key := tag.Key
+		if jsonReservedKeys[key] {
+			key = "tag_" + key
+		}
+		buf.WriteByte(',')
+		writeJSONValue(&buf, key)
+		buf.WriteByte(':')
+		writeJSONValue(&buf, Redact(tag.Value))
+	}
+
+	buf.WriteString("}\n")
+
+	jsonWriterMu.Lock()
+	defer jsonWriterMu.Unlock()
+	logger.Writer().Write(buf.Bytes())
+}
+
+// writeJSONValue writes v to buf as JSON. Errors are written as their message
+// and values that cannot be marshaled fall back to their string form.
+func writeJSONValue(buf *bytes.Buffer, v interface{}) {
+	if err, ok := v.(error); ok {
+		v = err.Error()
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprintf("%v", v))
+	}
+	buf.Write(b)
+}
+
 func init() {
 	logLevels = make(map[string]int, 6)
 	logLevels[LogNone] = LogLevelNone
@@ -3,11 +3,18 @@ package cliutil_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"os/exec"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/cpliakas/cliutil"
+	"github.com/go-test/deep"
 )
 
 func TestLogLevel(t *testing.T) {
@@ -90,3 +97,99 @@ func TestContextWithLogTagPanic(t *testing.T) {
 
 	cliutil.ContextWithLogTag(context.Background(), "test key", "test value")
 }
+
+func TestJSONMessageWriter(t *testing.T) {
+	ctx := context.Background()
+	ctx = cliutil.ContextWithLogTag(ctx, "user", "test user")
+	ctx = cliutil.ContextWithLogTag(ctx, "attempt", 3)
+	ctx = cliutil.ContextWithLogTag(ctx, "level", "tag level")
+
+	logger := cliutil.NewLogger(cliutil.LogInfo)
+	logger.SetMessageWriter(cliutil.JSONMessageWriter)
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+	logger.Error(ctx, "test message", errors.New("because reasons"))
+
+	var actual map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &actual); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := time.Parse(time.RFC3339Nano, actual["time"].(string)); err != nil {
+		t.Error(err)
+	}
+	delete(actual, "time")
+
+	ex := map[string]interface{}{
+		"level":     "ERROR",
+		"message":   "test message",
+		"error":     "because reasons",
+		"user":      "test user",
+		"attempt":   float64(3),
+		"tag_level": "tag level",
+	}
+	if diff := deep.Equal(actual, ex); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestJSONMessageWriterConcurrent(t *testing.T) {
+	logger := cliutil.NewLogger(cliutil.LogInfo)
+	logger.SetMessageWriter(cliutil.JSONMessageWriter)
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	var wg sync.WaitGroup
+	for idx := 0; idx < 10; idx++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger.Info(context.Background(), "test message")
+		}()
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 10 {
+		t.Fatalf("got %v lines, expected 10", len(lines))
+	}
+	for _, line := range lines {
+		if !json.Valid([]byte(line)) {
+			t.Errorf("invalid JSON: %s", line)
+		}
+	}
+}
+
+func TestLogTags(t *testing.T) {
+	parent := cliutil.ContextWithLogTag(context.Background(), "one", 1)
+	ctx1 := cliutil.ContextWithLogTag(parent, "two", 2)
+	ctx2 := cliutil.ContextWithLogTag(parent, "three", 3)
+
+	ex1 := []cliutil.LogTag{{Key: "one", Value: 1}, {Key: "two", Value: 2}}
+	if diff := deep.Equal(cliutil.LogTags(ctx1), ex1); diff != nil {
+		t.Error(diff)
+	}
+
+	ex2 := []cliutil.LogTag{{Key: "one", Value: 1}, {Key: "three", Value: 3}}
+	if diff := deep.Equal(cliutil.LogTags(ctx2), ex2); diff != nil {
+		t.Error(diff)
+	}
+}
+
+// See https://talks.golang.org/2014/testing.slide#23
+func TestFatalIfErrorExitCode(t *testing.T) {
+	if os.Getenv("CLIUTIL_TEST_FATAL_EXIT_CODE") == "1" {
+		logger := cliutil.NewLogger(cliutil.LogNone)
+		logger.FatalIfError(context.Background(), "test message", cliutil.NewConfigError(errors.New("because reasons")))
+		return
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=TestFatalIfErrorExitCode")
+	cmd.Env = append(os.Environ(), "CLIUTIL_TEST_FATAL_EXIT_CODE=1")
+	err := cmd.Run()
+	if e, ok := err.(*exec.ExitError); ok && e.ExitCode() == cliutil.ExitCodeConfig {
+		return
+	}
+	t.Fatalf("process ran with err %v, want exit status %v", err, cliutil.ExitCodeConfig)
+}
@@ -1,15 +1,15 @@
 package cliutil
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
-	"net/http"
-	"net/url"
-	"os"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -23,6 +23,11 @@ var (
 	ErrTypeNotSupported  = errors.New("type not supported")
 	ErrFuncNotRegistered = errors.New("option type func not registered")
 	ErrZeroValue         = errors.New("value is a zero value for its type")
+	ErrRequired          = errors.New("required option not set")
+	ErrInvalid           = errors.New("invalid value")
+
+	ErrValidatorNotRegistered = errors.New("validator func not registered")
+	ErrDuplicateOption        = errors.New("duplicate option")
 )
 
 var optmeta map[string]map[string]string
@@ -34,6 +39,14 @@ var optmeta map[string]map[string]string
 // - default
 // - usage
 // - func
+// - required
+// - persistent
+// - exclusive, together and onerequired
+// - complete
+// - env
+// - secret
+// - arg
+// - len, min, max, oneof, enum, regex and validate
 func SetOptionMetadata(name string, meta map[string]string) {
 	optmeta[name] = meta
 }
@@ -49,11 +62,15 @@ func mergeMetadata(tag map[string]string) (map[string]string, error) {
 		return tag, nil
 	}
 
+	merged := make(map[string]string, len(meta)+len(tag))
+	for k, v := range meta {
+		merged[k] = v
+	}
 	for k, v := range tag {
-		meta[k] = v
+		merged[k] = v
 	}
 
-	return meta, nil
+	return merged, nil
 }
 
 // OptionType is implemented by structs that set and read options.
@@ -81,8 +98,22 @@ func init() {
 		"string":            NewStringOption,
 		"int":               NewIntOption,
 		"bool":              NewBoolOption,
+		"int8":              NewInt8Option,
+		"int16":             NewInt16Option,
+		"int32":             NewInt32Option,
+		"int64":             NewInt64Option,
+		"uint":              NewUintOption,
+		"uint8":             NewUint8Option,
+		"uint16":            NewUint16Option,
+		"uint32":            NewUint32Option,
+		"uint64":            NewUint64Option,
+		"float32":           NewFloat32Option,
 		"float64":           NewFloat64Option,
+		"time.Duration":     NewDurationOption,
 		"[]int":             NewIntSliceOption,
+		"[]string":          NewStringSliceOption,
+		"[]bool":            NewBoolSliceOption,
+		"[]float64":         NewFloat64SliceOption,
 		"map[string]string": NewKeyValueOption,
 		"boolstring":        NewBoolStringOption,
 		"ioreader":          NewIOReaderOption,
@@ -94,12 +125,6 @@ func init() {
 func newOptionType(tag map[string]string, i interface{}) (OptionType, error) {
 	var fn OptionTypeFunc
 
-	var err error
-	tag, err = mergeMetadata(tag)
-	if err != nil {
-		return nil, err
-	}
-
 	if name, ok := tag["func"]; ok {
 		if fn, ok = optfn[name]; !ok {
 			return nil, ErrFuncNotRegistered
@@ -112,14 +137,50 @@ func newOptionType(tag map[string]string, i interface{}) (OptionType, error) {
 			fn = optfn["int"]
 		case bool:
 			fn = optfn["bool"]
+		case int8:
+			fn = optfn["int8"]
+		case int16:
+			fn = optfn["int16"]
+		case int32:
+			fn = optfn["int32"]
+		case int64:
+			fn = optfn["int64"]
+		case uint:
+			fn = optfn["uint"]
+		case uint8:
+			fn = optfn["uint8"]
+		case uint16:
+			fn = optfn["uint16"]
+		case uint32:
+			fn = optfn["uint32"]
+		case uint64:
+			fn = optfn["uint64"]
+		case float32:
+			fn = optfn["float32"]
 		case float64:
 			fn = optfn["float64"]
+		case time.Duration:
+			fn = optfn["time.Duration"]
 		case []int:
 			fn = optfn["[]int"]
+		case []string:
+			fn = optfn["[]string"]
+		case []bool:
+			fn = optfn["[]bool"]
+		case []float64:
+			fn = optfn["[]float64"]
 		case map[string]string:
 			fn = optfn["map[string]string"]
 		default:
-			return nil, ErrTypeNotSupported
+			t := reflect.TypeOf(i)
+			if t == nil {
+				return nil, ErrTypeNotSupported
+			}
+			parse, ok := parserFor(t)
+			if !ok {
+				return nil, ErrTypeNotSupported
+			}
+			return newParserOption(tag, t, parse), nil
 		}
 	}
 	return fn(tag), nil
@@ -223,6 +284,308 @@ func (opt *Float64Option) Read(cfg *viper.Viper, field reflect.Value) error {
 	return nil
 }
 
+// Int8Option implements Option for int8 options.
+type Int8Option struct {
+	tag map[string]string
+}
+
+// NewInt8Option is an OptionTypeFunc that returns an *Int8Option.
+func NewInt8Option(tag map[string]string) OptionType { return &Int8Option{tag} }
+
+// Set implements OptionType.Set.
+func (opt *Int8Option) Set(f *Flagger) (err error) {
+	var v int64
+	if s, ok := opt.tag["default"]; ok {
+		if v, err = strconv.ParseInt(s, 10, 8); err != nil {
+			return
+		}
+	}
+	f.Int8(opt.tag["option"], opt.tag["short"], int8(v), opt.tag["usage"])
+	return
+}
+
+// Read implements OptionType.Read.
+func (opt *Int8Option) Read(cfg *viper.Viper, field reflect.Value) error {
+	return readInt(cfg, opt.tag["option"], field)
+}
+
+// Int16Option implements Option for int16 options.
+type Int16Option struct {
+	tag map[string]string
+}
+
+// NewInt16Option is an OptionTypeFunc that returns an *Int16Option.
+func NewInt16Option(tag map[string]string) OptionType { return &Int16Option{tag} }
+
+// Set implements OptionType.Set.
+func (opt *Int16Option) Set(f *Flagger) (err error) {
+	var v int64
+	if s, ok := opt.tag["default"]; ok {
+		if v, err = strconv.ParseInt(s, 10, 16); err != nil {
+			return
+		}
+	}
+	f.Int16(opt.tag["option"], opt.tag["short"], int16(v), opt.tag["usage"])
+	return
+}
+
+// Read implements OptionType.Read.
+func (opt *Int16Option) Read(cfg *viper.Viper, field reflect.Value) error {
+	return readInt(cfg, opt.tag["option"], field)
+}
+
+// Int32Option implements Option for int32 options.
+type Int32Option struct {
+	tag map[string]string
+}
+
+// NewInt32Option is an OptionTypeFunc that returns an *Int32Option.
+func NewInt32Option(tag map[string]string) OptionType { return &Int32Option{tag} }
+
+// Set implements OptionType.Set.
+func (opt *Int32Option) Set(f *Flagger) (err error) {
+	var v int64
+	if s, ok := opt.tag["default"]; ok {
+		if v, err = strconv.ParseInt(s, 10, 32); err != nil {
+			return
+		}
+	}
+	f.Int32(opt.tag["option"], opt.tag["short"], int32(v), opt.tag["usage"])
+	return
+}
+
+// Read implements OptionType.Read.
+func (opt *Int32Option) Read(cfg *viper.Viper, field reflect.Value) error {
+	return readInt(cfg, opt.tag["option"], field)
+}
+
+// Int64Option implements Option for int64 options.
+type Int64Option struct {
+	tag map[string]string
+}
+
+// NewInt64Option is an OptionTypeFunc that returns an *Int64Option.
+func NewInt64Option(tag map[string]string) OptionType { return &Int64Option{tag} }
+
+// Set implements OptionType.Set.
+func (opt *Int64Option) Set(f *Flagger) (err error) {
+	var v int64
+	if s, ok := opt.tag["default"]; ok {
+		if v, err = strconv.ParseInt(s, 10, 64); err != nil {
+			return
+		}
+	}
+	f.Int64(opt.tag["option"], opt.tag["short"], int64(v), opt.tag["usage"])
+	return
+}
+
+// Read implements OptionType.Read.
+func (opt *Int64Option) Read(cfg *viper.Viper, field reflect.Value) error {
+	return readInt(cfg, opt.tag["option"], field)
+}
+
+// UintOption implements Option for uint options.
+type UintOption struct {
+	tag map[string]string
+}
+
+// NewUintOption is an OptionTypeFunc that returns a *UintOption.
+func NewUintOption(tag map[string]string) OptionType { return &UintOption{tag} }
+
+// Set implements OptionType.Set.
+func (opt *UintOption) Set(f *Flagger) (err error) {
+	var v uint64
+	if s, ok := opt.tag["default"]; ok {
+		if v, err = strconv.ParseUint(s, 10, 0); err != nil {
+			return
+		}
+	}
+	f.Uint(opt.tag["option"], opt.tag["short"], uint(v), opt.tag["usage"])
+	return
+}
+
+// Read implements OptionType.Read.
+func (opt *UintOption) Read(cfg *viper.Viper, field reflect.Value) error {
+	return readUint(cfg, opt.tag["option"], field)
+}
+
+// Uint8Option implements Option for uint8 options.
+type Uint8Option struct {
+	tag map[string]string
+}
+
+// NewUint8Option is an OptionTypeFunc that returns a *Uint8Option.
+func NewUint8Option(tag map[string]string) OptionType { return &Uint8Option{tag} }
+
+// Set implements OptionType.Set.
+func (opt *Uint8Option) Set(f *Flagger) (err error) {
+	var v uint64
+	if s, ok := opt.tag["default"]; ok {
+		if v, err = strconv.ParseUint(s, 10, 8); err != nil {
+			return
+		}
+	}
+	f.Uint8(opt.tag["option"], opt.tag["short"], uint8(v), opt.tag["usage"])
+	return
+}
+
+// Read implements OptionType.Read.
+func (opt *Uint8Option) Read(cfg *viper.Viper, field reflect.Value) error {
+	return readUint(cfg, opt.tag["option"], field)
+}
+
+// Uint16Option implements Option for uint16 options.
+type Uint16Option struct {
+	tag map[string]string
+}
+
+// NewUint16Option is an OptionTypeFunc that returns a *Uint16Option.
+func NewUint16Option(tag map[string]string) OptionType { return &Uint16Option{tag} }
+
+// Set implements OptionType.Set.
+func (opt *Uint16Option) Set(f *Flagger) (err error) {
+	var v uint64
+	if s, ok := opt.tag["default"]; ok {
+		if v, err = strconv.ParseUint(s, 10, 16); err != nil {
+			return
+		}
+	}
+	f.Uint16(opt.tag["option"], opt.tag["short"], uint16(v), opt.tag["usage"])
+	return
+}
+
+// Read implements OptionType.Read.
+func (opt *Uint16Option) Read(cfg *viper.Viper, field reflect.Value) error {
+	return readUint(cfg, opt.tag["option"], field)
+}
+
+// Uint32Option implements Option for uint32 options.
+type Uint32Option struct {
+	tag map[string]string
+}
+
+// NewUint32Option is an OptionTypeFunc that returns a *Uint32Option.
+func NewUint32Option(tag map[string]string) OptionType { return &Uint32Option{tag} }
+
+// Set implements OptionType.Set.
+func (opt *Uint32Option) Set(f *Flagger) (err error) {
+	var v uint64
+	if s, ok := opt.tag["default"]; ok {
+		if v, err = strconv.ParseUint(s, 10, 32); err != nil {
+			return
+		}
+	}
+	f.Uint32(opt.tag["option"], opt.tag["short"], uint32(v), opt.tag["usage"])
+	return
+}
+
+// Read implements OptionType.Read.
+func (opt *Uint32Option) Read(cfg *viper.Viper, field reflect.Value) error {
+	return readUint(cfg, opt.tag["option"], field)
+}
+
+// Uint64Option implements Option for uint64 options.
+type Uint64Option struct {
+	tag map[string]string
+}
+
+// NewUint64Option is an OptionTypeFunc that returns a *Uint64Option.
+func NewUint64Option(tag map[string]string) OptionType { return &Uint64Option{tag} }
+
+// Set implements OptionType.Set.
+func (opt *Uint64Option) Set(f *Flagger) (err error) {
+	var v uint64
+	if s, ok := opt.tag["default"]; ok {
+		if v, err = strconv.ParseUint(s, 10, 64); err != nil {
+			return
+		}
+	}
+	f.Uint64(opt.tag["option"], opt.tag["short"], uint64(v), opt.tag["usage"])
+	return
+}
+
+// Read implements OptionType.Read.
+func (opt *Uint64Option) Read(cfg *viper.Viper, field reflect.Value) error {
+	return readUint(cfg, opt.tag["option"], field)
+}
+
+// Float32Option implements Option for float32 options.
+type Float32Option struct {
+	tag map[string]string
+}
+
+// NewFloat32Option is an OptionTypeFunc that returns a *Float32Option.
+func NewFloat32Option(tag map[string]string) OptionType { return &Float32Option{tag} }
+
+// Set implements OptionType.Set.
+func (opt *Float32Option) Set(f *Flagger) (err error) {
+	var v float64
+	if s, ok := opt.tag["default"]; ok {
+		if v, err = strconv.ParseFloat(s, 32); err != nil {
+			return
+		}
+	}
+	f.Float32(opt.tag["option"], opt.tag["short"], float32(v), opt.tag["usage"])
+	return
+}
+
+// Read implements OptionType.Read.
+func (opt *Float32Option) Read(cfg *viper.Viper, field reflect.Value) error {
+	v := cfg.GetFloat64(opt.tag["option"])
+	if field.OverflowFloat(v) {
+		return fmt.Errorf("%v overflows %s", v, field.Type())
+	}
+	field.SetFloat(v)
+	return nil
+}
+
+// DurationOption implements Option for time.Duration options.
+type DurationOption struct {
+	tag map[string]string
+}
+
+// NewDurationOption is an OptionTypeFunc that returns a *DurationOption.
+func NewDurationOption(tag map[string]string) OptionType { return &DurationOption{tag} }
+
+// Set implements OptionType.Set.
+func (opt *DurationOption) Set(f *Flagger) (err error) {
+	var v time.Duration
+	if s, ok := opt.tag["default"]; ok {
+		if v, err = time.ParseDuration(s); err != nil {
+			return
+		}
+	}
+	f.Duration(opt.tag["option"], opt.tag["short"], v, opt.tag["usage"])
+	return
+}
+
+// Read implements OptionType.Read.
+func (opt *DurationOption) Read(cfg *viper.Viper, field reflect.Value) error {
+	field.SetInt(int64(cfg.GetDuration(opt.tag["option"])))
+	return nil
+}
+
+// readInt reads the named option into field, which must be a signed integer.
+func readInt(cfg *viper.Viper, name string, field reflect.Value) error {
+	v := cfg.GetInt64(name)
+	if field.OverflowInt(v) {
+		return fmt.Errorf("%v overflows %s", v, field.Type())
+	}
+	field.SetInt(v)
+	return nil
+}
+
+// readUint reads the named option into field, which must be an unsigned
+// integer.
+func readUint(cfg *viper.Viper, name string, field reflect.Value) error {
+	v := cfg.GetUint64(name)
+	if field.OverflowUint(v) {
+		return fmt.Errorf("%v overflows %s", v, field.Type())
+	}
+	field.SetUint(v)
+	return nil
+}
+
 // IntSliceOption implements Option for []int options.
 type IntSliceOption struct {
 	tag map[string]string
@@ -239,13 +602,139 @@ func (opt *IntSliceOption) Set(f *Flagger) error {
 
 // Read implements OptionType.Read.
 func (opt *IntSliceOption) Read(cfg *viper.Viper, field reflect.Value) error {
-	v, err := ParseIntSlice(cfg.GetString(opt.tag["option"]))
+	s, err := readStringSlice(cfg, opt.tag["option"])
+	if err != nil {
+		return err
+	}
+
+	v, err := ParseIntSlice(strings.Join(s, ","))
 	for _, val := range v {
 		field.Set(reflect.Append(field, reflect.ValueOf(val)))
 	}
 	return err
 }
 
+// StringSliceOption implements Option for []string options.
+type StringSliceOption struct {
+	tag map[string]string
+}
+
+// NewStringSliceOption is an OptionTypeFunc that returns a *StringSliceOption.
+func NewStringSliceOption(tag map[string]string) OptionType { return &StringSliceOption{tag} }
+
+// Set implements OptionType.Set.
+func (opt *StringSliceOption) Set(f *Flagger) error {
+	v, err := ParseStringSlice(opt.tag["default"])
+	if err != nil {
+		return err
+	}
+	f.StringSlice(opt.tag["option"], opt.tag["short"], v, opt.tag["usage"])
+	return nil
+}
+
+// Read implements OptionType.Read.
+func (opt *StringSliceOption) Read(cfg *viper.Viper, field reflect.Value) error {
+	v, err := readStringSlice(cfg, opt.tag["option"])
+	if err != nil {
+		return err
+	}
+	field.Set(reflect.ValueOf(v))
+	return nil
+}
+
+// BoolSliceOption implements Option for []bool options.
+type BoolSliceOption struct {
+	tag map[string]string
+}
+
+// NewBoolSliceOption is an OptionTypeFunc that returns a *BoolSliceOption.
+func NewBoolSliceOption(tag map[string]string) OptionType { return &BoolSliceOption{tag} }
+
+// Set implements OptionType.Set.
+func (opt *BoolSliceOption) Set(f *Flagger) error {
+	v, err := ParseBoolSlice(opt.tag["default"])
+	if err != nil {
+		return err
+	}
+	f.BoolSlice(opt.tag["option"], opt.tag["short"], v, opt.tag["usage"])
+	return nil
+}
+
+// Read implements OptionType.Read.
+func (opt *BoolSliceOption) Read(cfg *viper.Viper, field reflect.Value) error {
+	s, err := readStringSlice(cfg, opt.tag["option"])
+	if err != nil {
+		return err
+	}
+
+	v := make([]bool, len(s))
+	for idx := range s {
+		if v[idx], err = strconv.ParseBool(s[idx]); err != nil {
+			return fmt.Errorf("value at index %v is not a boolean: %w", idx, err)
+		}
+	}
+
+	field.Set(reflect.ValueOf(v))
+	return nil
+}
+
+// Float64SliceOption implements Option for []float64 options.
+type Float64SliceOption struct {
+	tag map[string]string
+}
+
+// NewFloat64SliceOption is an OptionTypeFunc that returns a *Float64SliceOption.
+func NewFloat64SliceOption(tag map[string]string) OptionType { return &Float64SliceOption{tag} }
+
+// Set implements OptionType.Set.
+func (opt *Float64SliceOption) Set(f *Flagger) error {
+	v, err := ParseFloat64Slice(opt.tag["default"])
+	if err != nil {
+		return err
+	}
+	f.Float64Slice(opt.tag["option"], opt.tag["short"], v, opt.tag["usage"])
+	return nil
+}
+
+// Read implements OptionType.Read.
+func (opt *Float64SliceOption) Read(cfg *viper.Viper, field reflect.Value) error {
+	s, err := readStringSlice(cfg, opt.tag["option"])
+	if err != nil {
+		return err
+	}
+
+	v := make([]float64, len(s))
+	for idx := range s {
+		if v[idx], err = strconv.ParseFloat(s[idx], 64); err != nil {
+			return fmt.Errorf("value at index %v is not a number: %w", idx, err)
+		}
+	}
+
+	field.Set(reflect.ValueOf(v))
+	return nil
+}
+
+// readStringSlice reads the named option as a []string. Flags bound to
+// slices are rendered by pflag as "[a,b]", whereas environment variables and
+// defaults are plain comma separated values, so both forms are accepted.
+func readStringSlice(cfg *viper.Viper, name string) ([]string, error) {
+	switch v := cfg.Get(name).(type) {
+	case nil:
+		return []string{}, nil
+	case []string:
+		return v, nil
+	case []interface{}:
+		s := make([]string, len(v))
+		for idx := range v {
+			s[idx] = fmt.Sprint(v[idx])
+		}
+		return s, nil
+	default:
+		s := strings.TrimSuffix(strings.TrimPrefix(fmt.Sprint(v), "["), "]")
+		return ParseStringSlice(s)
+	}
+}
+
 // BoolStringOption implements Option for string options.
 type BoolStringOption struct {
 	tag map[string]string
@@ -289,17 +778,66 @@ func (opt *KeyValueOption) Set(f *Flagger) error {
 
 // Read implements OptionType.Read.
 func (opt *KeyValueOption) Read(cfg *viper.Viper, field reflect.Value) error {
-	v := ParseKeyValue(cfg.GetString(opt.tag["option"]))
+	v := readKeyValue(cfg, opt.tag["option"])
 	field.Set(reflect.ValueOf(v))
 	return nil
 }
 
-// IOReaderOption implements Option for string options read from an io.Reader.
+// readKeyValue reads the named option as a map[string]string. Flags and
+// environment variables are strings parsed by ParseKeyValue, whereas
+// positional arguments and configuration files may set a list of key/value
+// pairs or a native map.
+func readKeyValue(cfg *viper.Viper, name string) map[string]string {
+	switch v := cfg.Get(name).(type) {
+	case nil:
+		return map[string]string{}
+	case map[string]string:
+		return v
+	case map[string]interface{}:
+		m := make(map[string]string, len(v))
+		for key, val := range v {
+			m[key] = fmt.Sprint(val)
+		}
+		return m
+	case []string:
+		return parseKeyValuePairs(v)
+	case []interface{}:
+		pairs := make([]string, len(v))
+		for idx := range v {
+			pairs[idx] = fmt.Sprint(v[idx])
+		}
+		return parseKeyValuePairs(pairs)
+	default:
+		return ParseKeyValue(cfg.GetString(name))
+	}
+}
+
+// parseKeyValuePairs parses a slice where each element is a single key/value
+// pair, so values may contain spaces without being quoted.
+func parseKeyValuePairs(pairs []string) map[string]string {
+	m := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		p := strings.SplitN(pair, "=", 2)
+		if len(p) < 2 {
+			p = append(p, "")
+		}
+		m[strings.TrimFunc(p[0], isQuotationMark)] = strings.TrimFunc(p[1], isQuotationMark)
+	}
+	return m
+}
+
+// IOReaderOption implements Option for options read from an io.Reader. The
+// option's value is a URI opened via OpenURI. Fields may be strings, []byte,
+// io.Reader or io.ReadCloser to stream the data, or any type decoded from JSON
+// or YAML. The tag's timeout key limits how long reading may take, which
+// defaults to DefaultURITimeout except for streams, the maxsize key limits the
+// number of bytes that are read, and the format key sets the format data is
+// decoded from.
 type IOReaderOption struct {
 	tag map[string]string
 }
 
-// NewIOReaderOption is a OptionTypeFunc that returns a *GroupOption.
+// NewIOReaderOption is a OptionTypeFunc that returns a *IOReaderOption.
 func NewIOReaderOption(tag map[string]string) OptionType { return &IOReaderOption{tag} }
 
 // Set implements OptionType.Set.
@@ -314,40 +852,34 @@ func (opt *IOReaderOption) Read(cfg *viper.Viper, field reflect.Value) error {
 		return nil
 	}
 
-	u, err := url.Parse(cfg.GetString(opt.tag["option"]))
-	if err != nil {
-		return fmt.Errorf("error parsing uri: %w", err)
-	}
-
-	r, err := getReadCloser(u)
-	if err != nil {
-		return err
-	}
-
-	b, err := ioutil.ReadAll(r)
-	if err != nil {
-		return fmt.Errorf("error reading data: %w", err)
-	}
-
-	field.SetString(string(b))
-	return nil
+	uri := cfg.GetString(opt.tag["option"])
+	return readInto(field, opt.tag, uri, func(ctx context.Context) (io.ReadCloser, error) {
+		return OpenURI(ctx, uri)
+	})
 }
 
-func getReadCloser(u *url.URL) (io.ReadCloser, error) {
-	switch u.Scheme {
-	case "file", "":
-		return os.Open(u.Path)
-	case "c":
-		return os.Open(u.String())
-	case "http", "https":
-		resp, err := http.Get(u.String())
-		return resp.Body, err
-	default:
-		return nil, fmt.Errorf("%s: scheme not supported", u.Scheme)
+// readLimits parses the timeout and maxsize keys of the tag.
+func readLimits(tag map[string]string) (timeout time.Duration, maxSize int64, err error) {
+	timeout = DefaultURITimeout
+	if s, ok := tag["timeout"]; ok {
+		if timeout, err = time.ParseDuration(s); err != nil {
+			return
+		}
 	}
+	if s, ok := tag["maxsize"]; ok {
+		if maxSize, err = strconv.ParseInt(s, 10, 64); err != nil {
+			return
+		}
+	}
+	return
 }
 
-// StdinOption implements Option for string options read via stdin.
+// StdinOption implements Option for options read via stdin. The value "-"
+// reads STDIN, an empty value reads STDIN only if data was piped or
+// redirected to the process, and all other values are used as the data.
+// Fields may be of the same types as IOReaderOption. The tag's timeout key
+// limits how long reading may take, and the maxsize key limits the number of
+// bytes that are read.
 type StdinOption struct {
 	tag map[string]string
 }
@@ -362,42 +894,84 @@ func (opt *StdinOption) Set(f *Flagger) error {
 }
 
 // Read implements OptionType.Read.
-func (opt *StdinOption) Read(cfg *viper.Viper, field reflect.Value) (err error) {
-	s := cfg.GetString(opt.tag["option"])
-	if s == "" {
-		if s, err = readStdin(); err != nil {
-			return err
-		}
-	}
-	field.SetString(s)
-	return nil
-}
-
-func readStdin() (string, error) {
-	stat, err := os.Stdin.Stat()
+func (opt *StdinOption) Read(cfg *viper.Viper, field reflect.Value) error {
+	timeout, _, err := readLimits(opt.tag)
 	if err != nil {
-		return "", fmt.Errorf("error getting info for stdin: %w", err)
+		return err
 	}
-
-	if stat.Size() == 0 {
-		return "", nil
+	if _, ok := opt.tag["timeout"]; !ok {
+		timeout = 0
 	}
 
-	b, err := ioutil.ReadAll(os.Stdin)
-	if err != nil {
-		return "", fmt.Errorf("error reading stdin: %w", err)
+	// STDIN enforces the timeout itself so that blocked reads return.
+	tag := make(map[string]string, len(opt.tag)+1)
+	for k, v := range opt.tag {
+		tag[k] = v
 	}
+	tag["timeout"] = "0"
 
-	return string(b), err
+	src := NewStdinSource(timeout)
+	return readInto(field, tag, "", src.opener(cfg.GetString(opt.tag["option"])))
 }
 
 // SetOptions sets flags based on the cliutil tag.
+//
+// Options with the arg key are positional arguments instead of flags. Their
+// position is set via arg=0, arg=1, etc., and arg=rest collects the remaining
+// arguments. SetOptions generates cobra.Command.Use and cobra.Command.Args
+// from them, and ReadOptions reads them like any other option.
+//
+// Options with the persistent key are added as persistent flags that are
+// inherited by subcommands. ReadOptions reads them from the configuration of
+// any subcommand that was passed to NewFlagger or AddCommand.
 func (f *Flagger) SetOptions(a interface{}) error {
 	rv, rt, err := resolveStruct(a)
 	if err != nil {
 		return err
 	}
 
+	st := &setState{names: make(map[string]string), groups: make(map[string][]string)}
+	if err := f.setOptions(rv, rt, "", st); err != nil {
+		return err
+	}
+
+	// Register the flag groups in a stable order.
+	keys := make([]string, 0, len(st.groups))
+	for key := range st.groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		kind := strings.SplitN(key, "=", 2)[0]
+		f.addFlagGroup(kind, st.groups[key], true)
+	}
+
+	return f.setArgs(st.args)
+}
+
+// setState tracks the positional arguments, option names and flag groups
+// found while setting options.
+type setState struct {
+	args   []argSpec
+	names  map[string]string
+	groups map[string][]string
+}
+
+// add records an option's name and returns an error if it is already used
+// by another field or flag.
+func (st *setState) add(f *Flagger, name, field string) error {
+	if other, ok := st.names[name]; ok {
+		return fmt.Errorf("option %s: %w: set by fields %s and %s", name, ErrDuplicateOption, other, field)
+	}
+	if f.lookupFlag(name) != nil {
+		return fmt.Errorf("option %s: %w: flag already exists", name, ErrDuplicateOption)
+	}
+	st.names[name] = field
+	return nil
+}
+
+func (f *Flagger) setOptions(rv reflect.Value, rt reflect.Type, prefix string, st *setState) error {
+
 	// Iterate over the struct's field.
 	for idx := 0; idx < rt.NumField(); idx++ {
 
@@ -409,29 +983,87 @@ func (f *Flagger) SetOptions(a interface{}) error {
 
 		i := rvf.Interface()
 
-		// Recurse into structs.
-		if rvf.Kind() == reflect.Struct {
-			if err := f.SetOptions(i); err != nil {
+		// Recurse into structs that aren't options themselves.
+		if rvf.Kind() == reflect.Struct && !isOption(rtf) {
+			if err := f.setOptions(rvf, rvf.Type(), nestedPrefix(prefix, rtf), st); err != nil {
 				return err
 			}
 			continue
 		}
 
 		// Parse the option from the tag.
-		tag := parseTag(rtf)
-		if _, ok := tag["option"]; !ok {
+		tag, ok := parseOptionTag(rtf)
+		if !ok {
 			continue
 		}
+		tag = prefixOption(prefix, tag)
+
+		// Detect options that collide with other options.
+		names := []string{tag["option"]}
+		if tagBool(tag, "secret") {
+			names = append(names, secretFileOption(tag["option"]))
+		}
+		for _, name := range names {
+			if err := st.add(f, name, rt.Name()+"."+rtf.Name); err != nil {
+				return err
+			}
+		}
+		tagGroups(st.groups, tag)
 
 		// Set the OptionType either from tag["func"] or the type of i.
 		opt, err := newOptionType(tag, i)
 		if err != nil {
 			return fmt.Errorf("option %s: %w", tag["option"], err)
 		}
+		if s, ok := tag["env"]; ok {
+			f.BindEnv(tag["option"], strings.Split(s, ",")...)
+		}
+		f.addOptionInfo(tag, rvf.Type())
+
+		complete, err := completionFunc(tag)
+		if err != nil {
+			return fmt.Errorf("option %s: %w", tag["option"], err)
+		}
+
+		// Positional arguments are set by the Args validator.
+		spec, ok, err := newArgSpec(tag)
+		if err != nil {
+			return fmt.Errorf("option %s: %w", tag["option"], err)
+		}
+		if ok {
+			spec.complete = complete
+			if s, ok := tag["default"]; ok {
+				f.cfg.SetDefault(tag["option"], s)
+			}
+			st.args = append(st.args, spec)
+			continue
+		}
 
-		if err := opt.Set(f); err != nil {
+		// Persistent options are inherited by subcommands.
+		fl := f
+		if tagBool(tag, "persistent") {
+			fl = f.persistentFlagger()
+		}
+
+		if err := opt.Set(fl); err != nil {
 			return fmt.Errorf("error setting option %s: %w", tag["option"], err)
 		}
+
+		if complete != nil {
+			if err := f.cmd.RegisterFlagCompletionFunc(tag["option"], complete); err != nil {
+				return fmt.Errorf("error setting option %s: %w", tag["option"], err)
+			}
+		}
+
+		// Hide the defaults of secrets from help and allow secrets to be
+		// read from files instead of passing them on the command line.
+		if tagBool(tag, "secret") {
+			if flag := fl.flags().Lookup(tag["option"]); flag != nil && tag["default"] != "" {
+				flag.DefValue = RedactedValue
+			}
+			usage := fmt.Sprintf("read --%s from a file", tag["option"])
+			fl.String(secretFileOption(tag["option"]), "", "", usage)
+		}
 	}
 
 	return nil
@@ -443,15 +1075,47 @@ func (f *Flagger) SetOptions(a interface{}) error {
 func GetOptions(a interface{}, cfg *viper.Viper) error { return ReadOptions(a, cfg) }
 
 // ReadOptions reads options from cfg into a.
+//
+// An OptionErrors is returned that lists every option tagged as required that
+// was not set via flag, environment variable or configuration, as well as
+// every option that failed its validation rules.
 func ReadOptions(a interface{}, cfg *viper.Viper) (err error) {
 	rv, rt, err := resolveStruct(a)
 	if err != nil {
 		return err
 	}
-	return readOptions(rv, rt, cfg)
+
+	// Read options set via persistent flags of parent commands and
+	// environment variable aliases.
+	bindInheritedFlags(cfg)
+	bindEnvAliases(cfg)
+
+	st := &readState{names: make(map[string]bool)}
+	if _, err = readOptions(rv, rt, cfg, "", st); err != nil {
+		return err
+	}
+	checkFlagGroups(cfg, st.names, &st.errs)
+	if len(st.errs) > 0 {
+		return st.errs
+	}
+
+	return nil
 }
 
-func readOptions(rv reflect.Value, rt reflect.Type, cfg *viper.Viper) error {
+// readState tracks the errors and option names found while reading options.
+// Options are read leniently if show is true, i.e., required options and
+// validation rules aren't checked, options that fail to read are left unset
+// and options with func=ioreader or func=stdin aren't read, so that their
+// values can be shown even if the configuration is invalid.
+type readState struct {
+	errs  OptionErrors
+	names map[string]bool
+	show  bool
+}
+
+// readOptions reads the options of a struct and returns true if any of them
+// were set.
+func readOptions(rv reflect.Value, rt reflect.Type, cfg *viper.Viper, prefix string, st *readState) (set bool, err error) {
 
 	// Iterate over the struct's field.
 	for idx := 0; idx < rt.NumField(); idx++ {
@@ -462,41 +1126,107 @@ func readOptions(rv reflect.Value, rt reflect.Type, cfg *viper.Viper) error {
 			continue
 		}
 
-		// Recurse into structs.
-		if rvf.Kind() == reflect.Struct {
-			err := readOptions(rvf, rvf.Type(), cfg)
+		// Recurse into structs that aren't options themselves, and allocate
+		// nil pointers to structs if any of their options were set.
+		if rvf.Kind() == reflect.Struct && !isOption(rtf) {
+			nested, err := readOptions(rvf, rvf.Type(), cfg, nestedPrefix(prefix, rtf), st)
 			if err != nil {
-				return err
+				return set, err
 			}
+			if nested {
+				setPointer(rv.Field(idx), rvf)
+			}
+			set = set || nested
 			continue
 		}
 
 		// Skip fields that cannot be set.
-		field := rv.FieldByName(rtf.Name)
-		if !field.CanSet() {
+		if !rv.Field(idx).CanSet() {
 			continue
 		}
 
+		// Read pointers into the values they point to. Nil pointers are
+		// only allocated if the option was set.
+		field := rv.Field(idx)
+		if field.Kind() == reflect.Ptr {
+			field = rvf
+		}
+
 		// Parse the option from the tag.
-		tag := parseTag(rtf)
-		if _, ok := tag["option"]; !ok {
+		tag, ok := parseOptionTag(rtf)
+		if !ok {
 			continue
 		}
+		tag = prefixOption(prefix, tag)
+		st.names[tag["option"]] = true
 
 		// Get the OptionType either from tag["func"] or the type of i.
 		i := rvf.Interface()
 		opt, err := newOptionType(tag, i)
 		if err != nil {
-			return fmt.Errorf("option %s: %w", tag["option"], err)
+			return set, fmt.Errorf("option %s: %w", tag["option"], err)
+		}
+
+		// Record required options that weren't set and move on.
+		isSet := isOptionSet(cfg, tag)
+		if st.show {
+			switch tag["func"] {
+			case "ioreader", "stdin":
+				continue
+			}
+		} else if tagBool(tag, "required") && !isSet {
+			st.errs = append(st.errs, newOptionError(cfg, tag["option"], ErrRequired))
+			continue
+		}
+		set = set || isSet
+
+		// Leave pointers nil if the option wasn't set.
+		if rv.Field(idx).Kind() == reflect.Ptr && rv.Field(idx).IsNil() && !isSet {
+			continue
 		}
 
 		// Read the option from cfg into field.
 		if err := opt.Read(cfg, field); err != nil {
-			return fmt.Errorf("error reading option %s: %w", tag["option"], err)
+			if st.show {
+				continue
+			}
+			return set, fmt.Errorf("error reading option %s: %w", tag["option"], err)
+		}
+
+		// Read secrets from files.
+		secret := tagBool(tag, "secret")
+		if secret {
+			if err := readSecretFile(cfg, tag["option"], field); err != nil && !st.show {
+				return set, fmt.Errorf("error reading option %s: %w", tag["option"], err)
+			}
+		}
+		setPointer(rv.Field(idx), field)
+
+		// Required options must not be set to the zero value of their type,
+		// e.g., --token "".
+		if !st.show && tagBool(tag, "required") && field.IsZero() {
+			st.errs = append(st.errs, newOptionError(cfg, tag["option"], ErrZeroValue))
+			continue
+		}
+
+		// Validate options that were either set or have a non-zero value.
+		if st.show || (field.IsZero() && !isSet) {
+			continue
+		}
+		if err := validateOption(tag, field); err != nil {
+			if !errors.Is(err, ErrInvalid) {
+				return set, fmt.Errorf("option %s: %w", tag["option"], err)
+			}
+
+			// Don't leak the values of secrets in error messages.
+			if secret {
+				err = fmt.Errorf("%w: value of secret does not satisfy its validation rules", ErrInvalid)
+			}
+			st.errs = append(st.errs, newOptionError(cfg, tag["option"], err))
 		}
 	}
 
-	return nil
+	return set, nil
 }
 
 // Adapted from html/template/content.go, https://github.com/spf13/cast.
@@ -538,10 +1268,11 @@ func resolveField(v reflect.Value, t reflect.Type, idx int) (reflect.Value, refl
 		return vf, tf, true
 	}
 
-	// Resolve pointers, skip pointers with zero values to avoid panics.
+	// Resolve pointers. Nil pointers resolve to a new zero value so that
+	// their options can be set and read.
 	if vf.Kind() == reflect.Ptr {
-		if vf.IsZero() {
-			return vf, tf, true
+		if vf.IsNil() {
+			return reflect.New(vf.Type().Elem()).Elem(), tf, false
 		}
 		vf = vf.Elem()
 	}
@@ -549,6 +1280,71 @@ func resolveField(v reflect.Value, t reflect.Type, idx int) (reflect.Value, refl
 	return vf, tf, false
 }
 
+// parseOptionTag parses the cliutil tag and merges in metadata set via
+// SetOptionMetadata. False is returned if the field doesn't define an option.
+func parseOptionTag(f reflect.StructField) (map[string]string, bool) {
+	tag := parseTag(f)
+	if _, ok := tag["option"]; !ok {
+		return tag, false
+	}
+	tag, _ = mergeMetadata(tag)
+	return tag, true
+}
+
+// setPointer points ptr to v if ptr is a nil pointer.
+func setPointer(ptr, v reflect.Value) {
+	if ptr.Kind() == reflect.Ptr && ptr.IsNil() && ptr.CanSet() {
+		ptr.Set(v.Addr())
+	}
+}
+
+// nestedPrefix returns the prefix of the options in a nested struct, which is
+// the struct field's prefix key appended to the prefix of its parent.
+func nestedPrefix(prefix string, f reflect.StructField) string {
+	if p := parseTag(f)["prefix"]; p != "" {
+		return prefix + p + "-"
+	}
+	return prefix
+}
+
+// prefixOption returns a copy of tag with the option name prefixed.
+func prefixOption(prefix string, tag map[string]string) map[string]string {
+	if prefix == "" {
+		return tag
+	}
+	prefixed := make(map[string]string, len(tag))
+	for k, v := range tag {
+		prefixed[k] = v
+	}
+	prefixed["option"] = prefix + tag["option"]
+	return prefixed
+}
+
+// isOption returns true if the field defines an option.
+func isOption(f reflect.StructField) bool {
+	_, ok := parseTag(f)["option"]
+	return ok
+}
+
+// tagBool returns true if key is in tag without a value or with a value that
+// parses as true, e.g., "required" or "required=true".
+func tagBool(tag map[string]string, key string) bool {
+	s, ok := tag[key]
+	if !ok {
+		return false
+	}
+	if s == "" {
+		return true
+	}
+	v, _ := strconv.ParseBool(s)
+	return v
+}
+
+// newOptionError returns an *OptionError for the named option.
+func newOptionError(cfg *viper.Viper, name string, err error) *OptionError {
+	return &OptionError{Option: name, EnvVar: EnvVar(cfg, name), Err: err}
+}
+
 func parseTag(f reflect.StructField) (m map[string]string) {
 	m = make(map[string]string)
 	tag := f.Tag.Get(TagName)
@@ -1,9 +1,11 @@
 package cliutil_test
 
 import (
+	"errors"
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/cpliakas/cliutil"
 	"github.com/go-test/deep"
@@ -38,6 +40,42 @@ type InputEmbeddedPtr struct {
 	ValueFour float64 `cliutil:"option=value-four default=3.14"`
 }
 
+// newTestCommand returns a command that sets its options from input and reads
+// them into input via cfg when executed.
+func newTestCommand(t *testing.T, cfg *viper.Viper, input interface{}) *cobra.Command {
+	cmd := &cobra.Command{
+		Use: "test",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cliutil.ReadOptions(input, cfg)
+		},
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	cmd.SetOut(ioutil.Discard)
+	if err := cliutil.NewFlagger(cmd, cfg).SetOptions(input); err != nil {
+		t.Fatal(err)
+	}
+	return cmd
+}
+
+// tempConfig writes data to a temporary configuration file and returns its
+// name. Callers are responsible for removing the file.
+func tempConfig(t *testing.T, data string) string {
+	tmpfile, err := ioutil.TempFile(os.TempDir(), "cliutil-*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpfile.Close()
+	writeConfig(t, tmpfile.Name(), data)
+	return tmpfile.Name()
+}
+
+func writeConfig(t *testing.T, name, data string) {
+	if err := ioutil.WriteFile(name, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestReadOptions(t *testing.T) {
 	cmd := &cobra.Command{
 		Use:   "test",
@@ -104,6 +142,81 @@ func TestReadOptions(t *testing.T) {
 	}
 }
 
+type KindsInput struct {
+	Int8         int8          `cliutil:"option=int8 default=-8"`
+	Int16        int16         `cliutil:"option=int16 default=-16"`
+	Int32        int32         `cliutil:"option=int32 default=-32"`
+	Int64        int64         `cliutil:"option=int64 default=-64"`
+	Uint         uint          `cliutil:"option=uint default=1"`
+	Uint8        uint8         `cliutil:"option=uint8 default=8"`
+	Uint16       uint16        `cliutil:"option=uint16 default=16"`
+	Uint32       uint32        `cliutil:"option=uint32 default=32"`
+	Uint64       uint64        `cliutil:"option=uint64 default=64"`
+	Float32      float32       `cliutil:"option=float32 default=1.5"`
+	Duration     time.Duration `cliutil:"option=duration default=1m30s"`
+	StringSlice  []string      `cliutil:"option=string-slice default=a,b"`
+	BoolSlice    []bool        `cliutil:"option=bool-slice default=true,false"`
+	Float64Slice []float64     `cliutil:"option=float64-slice"`
+}
+
+func TestReadOptionsKinds(t *testing.T) {
+	cmd := &cobra.Command{
+		Use:   "test",
+		Short: "test read options of every kind",
+		Run:   func(cmd *cobra.Command, args []string) {},
+	}
+
+	v := viper.New()
+	flags := cliutil.NewFlagger(cmd, v)
+
+	input := &KindsInput{}
+	if err := flags.SetOptions(input); err != nil {
+		t.Fatal(err)
+	}
+
+	err := cmd.ParseFlags([]string{"--uint16", "8080", "--string-slice", "c,d", "--bool-slice", "true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a value passed through an environment variable.
+	v.Set("float64-slice", "1.5,2")
+
+	if err := cliutil.ReadOptions(input, v); err != nil {
+		t.Fatal(err)
+	}
+
+	ex := &KindsInput{
+		Int8:         -8,
+		Int16:        -16,
+		Int32:        -32,
+		Int64:        -64,
+		Uint:         1,
+		Uint8:        8,
+		Uint16:       8080,
+		Uint32:       32,
+		Uint64:       64,
+		Float32:      1.5,
+		Duration:     90 * time.Second,
+		StringSlice:  []string{"c", "d"},
+		BoolSlice:    []bool{true},
+		Float64Slice: []float64{1.5, 2},
+	}
+	if diff := deep.Equal(input, ex); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestReadOptionsOverflow(t *testing.T) {
+	v := viper.New()
+	v.Set("int8", "300")
+
+	input := &KindsInput{}
+	if err := cliutil.ReadOptions(input, v); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
 type IOReaderInput struct {
 	Data string `cliutil:"option=data func=ioreader"`
 }
@@ -250,3 +363,201 @@ func TestMergeMeta(t *testing.T) {
 		t.Errorf("got %q, expected %q", actual, ex)
 	}
 }
+
+type RequiredInput struct {
+	Token  string `cliutil:"option=token required"`
+	Region string `cliutil:"option=region required=true"`
+	Debug  bool   `cliutil:"option=debug required=false"`
+}
+
+func TestReadOptionsRequired(t *testing.T) {
+	cmd := &cobra.Command{
+		Use:   "test",
+		Short: "test required options",
+		Run:   func(cmd *cobra.Command, args []string) {},
+	}
+
+	v := cliutil.InitConfig("CLIUTIL_TEST")
+	flags := cliutil.NewFlagger(cmd, v)
+
+	input := &RequiredInput{}
+	if err := flags.SetOptions(input); err != nil {
+		t.Fatal(err)
+	}
+
+	if ann, ok := cmd.Flags().Lookup("token").Annotations[cobra.BashCompOneRequiredFlag]; ok {
+		t.Errorf("got %v, expected no required annotation", ann)
+	}
+
+	os.Setenv("CLIUTIL_TEST_REGION", "us-east-1")
+	defer os.Unsetenv("CLIUTIL_TEST_REGION")
+
+	err := cliutil.ReadOptions(input, v)
+	if !errors.Is(err, cliutil.ErrRequired) {
+		t.Fatalf("got %v, expected %v", err, cliutil.ErrRequired)
+	}
+
+	var errs cliutil.OptionErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("got %T, expected cliutil.OptionErrors", err)
+	}
+
+	ex := "--token (CLIUTIL_TEST_TOKEN): required option not set"
+	if actual := errs.Error(); actual != ex {
+		t.Errorf("got %q, expected %q", actual, ex)
+	}
+
+	if actual := input.Region; actual != "us-east-1" {
+		t.Errorf("got %q, expected %q", actual, "us-east-1")
+	}
+}
+
+func TestReadOptionsRequiredZero(t *testing.T) {
+	v := cliutil.InitConfig("CLIUTIL_TEST")
+	input := &RequiredInput{}
+	cmd := newTestCommand(t, v, input)
+
+	cmd.SetArgs([]string{"--token", "", "--region", "us-east-1"})
+	err := cmd.Execute()
+	if !errors.Is(err, cliutil.ErrZeroValue) {
+		t.Fatalf("got %v, expected %v", err, cliutil.ErrZeroValue)
+	}
+	if code := cliutil.ExitCode(err); code != cliutil.ExitCodeUsage {
+		t.Errorf("got exit code %d, expected %d", code, cliutil.ExitCodeUsage)
+	}
+}
+
+func TestReadOptionsRequiredExecute(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string
+		err  error
+	}{
+		{"env", "secret", nil},
+		{"missing", "", cliutil.ErrRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env != "" {
+				os.Setenv("CLIUTIL_TEST_TOKEN", tt.env)
+				defer os.Unsetenv("CLIUTIL_TEST_TOKEN")
+			}
+			os.Setenv("CLIUTIL_TEST_REGION", "us-east-1")
+			defer os.Unsetenv("CLIUTIL_TEST_REGION")
+
+			input := &RequiredInput{}
+			cmd := newTestCommand(t, cliutil.InitConfig("CLIUTIL_TEST"), input)
+
+			cmd.SetArgs([]string{})
+			if err := cmd.Execute(); !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, expected %v", err, tt.err)
+			}
+			if actual := input.Token; actual != tt.env {
+				t.Errorf("got %q, expected %q", actual, tt.env)
+			}
+		})
+	}
+}
+
+type PrefixInput struct {
+	DB    PrefixConn `cliutil:"prefix=db"`
+	Cache PrefixConn `cliutil:"prefix=cache"`
+}
+
+type PrefixConn struct {
+	Timeout time.Duration `cliutil:"option=timeout default=5s"`
+}
+
+func TestSetOptionsPrefix(t *testing.T) {
+	os.Setenv("CLIUTIL_TEST_DB_TIMEOUT", "10s")
+	defer os.Unsetenv("CLIUTIL_TEST_DB_TIMEOUT")
+
+	cmd := &cobra.Command{Use: "test"}
+	v := cliutil.InitConfig("CLIUTIL_TEST")
+
+	input := &PrefixInput{}
+	if err := cliutil.NewFlagger(cmd, v).SetOptions(input); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"db-timeout", "cache-timeout"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("expected flag --%s to be set", name)
+		}
+	}
+	if ex := "CLIUTIL_TEST_DB_TIMEOUT"; cliutil.EnvVar(v, "db-timeout") != ex {
+		t.Errorf("got %q, expected %q", cliutil.EnvVar(v, "db-timeout"), ex)
+	}
+
+	if err := cliutil.ReadOptions(input, v); err != nil {
+		t.Fatal(err)
+	}
+	if ex := 10 * time.Second; input.DB.Timeout != ex {
+		t.Errorf("db: got %s, expected %s", input.DB.Timeout, ex)
+	}
+	if ex := 5 * time.Second; input.Cache.Timeout != ex {
+		t.Errorf("cache: got %s, expected %s", input.Cache.Timeout, ex)
+	}
+}
+
+type DuplicateInput struct {
+	DB    PrefixConn
+	Cache PrefixConn
+}
+
+func TestSetOptionsDuplicate(t *testing.T) {
+	cmd := &cobra.Command{Use: "test"}
+	err := cliutil.NewFlagger(cmd, viper.New()).SetOptions(&DuplicateInput{})
+	if !errors.Is(err, cliutil.ErrDuplicateOption) {
+		t.Errorf("got %v, expected %v", err, cliutil.ErrDuplicateOption)
+	}
+}
+
+type PointerInput struct {
+	DB      *PointerDB `cliutil:"prefix=db"`
+	Cache   *PointerDB `cliutil:"prefix=cache"`
+	Name    *string    `cliutil:"option=name"`
+	Retries *int       `cliutil:"option=retries default=3"`
+	Verbose *bool      `cliutil:"option=verbose"`
+}
+
+type PointerDB struct {
+	Host string `cliutil:"option=host default=localhost"`
+}
+
+func TestReadOptionsPointers(t *testing.T) {
+	cmd := &cobra.Command{Use: "test", Run: func(cmd *cobra.Command, args []string) {}}
+	v := viper.New()
+
+	input := &PointerInput{}
+	if err := cliutil.NewFlagger(cmd, v).SetOptions(input); err != nil {
+		t.Fatal(err)
+	}
+	cmd.SetArgs([]string{"--db-host", "db.example.com", "--retries", "0"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cliutil.ReadOptions(input, v); err != nil {
+		t.Fatal(err)
+	}
+
+	if input.DB == nil {
+		t.Fatal("db: got nil, expected allocated struct")
+	}
+	if ex := "db.example.com"; input.DB.Host != ex {
+		t.Errorf("db: got %q, expected %q", input.DB.Host, ex)
+	}
+	if input.Cache != nil {
+		t.Errorf("cache: got %v, expected nil", input.Cache)
+	}
+	if input.Name != nil {
+		t.Errorf("name: got %q, expected nil", *input.Name)
+	}
+	if input.Retries == nil || *input.Retries != 0 {
+		t.Errorf("retries: got %v, expected 0", input.Retries)
+	}
+	if input.Verbose != nil {
+		t.Errorf("verbose: got %t, expected nil", *input.Verbose)
+	}
+}
@@ -1,6 +1,7 @@
 package cliutil
 
 import (
+	"encoding/csv"
 	"fmt"
 	"math"
 	"strconv"
@@ -32,7 +33,7 @@ func ParseKeyValue(s string) map[string]string {
 	// Build and return the key/value map.
 	m := make(map[string]string)
 	for _, part := range parts {
-		p := strings.Split(part, "=")
+		p := strings.SplitN(part, "=", 2)
 
 		// Protect against values with no "=", treat them as a key.
 		if len(p) < 2 {
@@ -103,6 +104,60 @@ func parseRange(elem string, idx int) (out []int, err error) {
 	return
 }
 
+// ParseStringSlice parses a slice of strings from comma separated values,
+// e.g., a,"b,c",d = []string{"a", "b,c", "d"}.
+func ParseStringSlice(s string) ([]string, error) {
+	if s == "" {
+		return []string{}, nil
+	}
+
+	r := csv.NewReader(strings.NewReader(s))
+	r.TrimLeadingSpace = true
+
+	out, err := r.Read()
+	if err != nil {
+		return []string{}, fmt.Errorf("error parsing values: %w", err)
+	}
+
+	return out, nil
+}
+
+// ParseBoolSlice parses a slice of booleans from comma separated values,
+// e.g., true,false,1 = []bool{true, false, true}.
+func ParseBoolSlice(s string) ([]bool, error) {
+	elems, err := ParseStringSlice(s)
+	if err != nil {
+		return []bool{}, err
+	}
+
+	out := make([]bool, len(elems))
+	for idx, elem := range elems {
+		if out[idx], err = strconv.ParseBool(strings.TrimSpace(elem)); err != nil {
+			return []bool{}, fmt.Errorf("value at index %v is not a boolean: %w", idx, err)
+		}
+	}
+
+	return out, nil
+}
+
+// ParseFloat64Slice parses a slice of 64-bit floats from comma separated
+// values, e.g., 1.5,2,3.25 = []float64{1.5, 2, 3.25}.
+func ParseFloat64Slice(s string) ([]float64, error) {
+	elems, err := ParseStringSlice(s)
+	if err != nil {
+		return []float64{}, err
+	}
+
+	out := make([]float64, len(elems))
+	for idx, elem := range elems {
+		if out[idx], err = strconv.ParseFloat(strings.TrimSpace(elem), 64); err != nil {
+			return []float64{}, fmt.Errorf("value at index %v is not a number: %w", idx, err)
+		}
+	}
+
+	return out, nil
+}
+
 // Sequence returns a sequencce of numbers between start and end as an []int.
 func Sequence(start, end int) []int {
 	a := make([]int, int(math.Abs(float64(start-end)))+1)
@@ -8,7 +8,7 @@ import (
 )
 
 func TestParseKeyValue(t *testing.T) {
-	s := `time="2017-05-30T19:02:08-05:00" level=info msg="some log message" no_value "key with space"=val`
+	s := `time="2017-05-30T19:02:08-05:00" level=info msg="some log message" no_value "key with space"=val expr='a=b'`
 	m := cliutil.ParseKeyValue(s)
 
 	tests := []struct {
@@ -20,6 +20,7 @@ func TestParseKeyValue(t *testing.T) {
 		{"msg", "some log message"},
 		{"no_value", ""},
 		{"key with space", "val"},
+		{"expr", "a=b"},
 	}
 
 	for _, tt := range tests {
@@ -51,3 +52,70 @@ func TestParseIntSlice(t *testing.T) {
 		}
 	}
 }
+
+func TestParseStringSlice(t *testing.T) {
+	tests := []struct {
+		s     string
+		ex    []string
+		exErr bool
+	}{
+		{"a,b, c", []string{"a", "b", "c"}, false},
+		{`a,"b,c",d`, []string{"a", "b,c", "d"}, false},
+		{"", []string{}, false},
+		{`a,"b`, []string{}, true},
+	}
+
+	for _, tt := range tests {
+		actual, err := cliutil.ParseStringSlice(tt.s)
+		if (err != nil) != tt.exErr {
+			t.Errorf("got error %v, expected error %t", err, tt.exErr)
+		}
+		if diff := deep.Equal(actual, tt.ex); diff != nil {
+			t.Error(diff)
+		}
+	}
+}
+
+func TestParseBoolSlice(t *testing.T) {
+	tests := []struct {
+		s     string
+		ex    []bool
+		exErr bool
+	}{
+		{"true,false, 1", []bool{true, false, true}, false},
+		{"", []bool{}, false},
+		{"true,maybe", []bool{}, true},
+	}
+
+	for _, tt := range tests {
+		actual, err := cliutil.ParseBoolSlice(tt.s)
+		if (err != nil) != tt.exErr {
+			t.Errorf("got error %v, expected error %t", err, tt.exErr)
+		}
+		if diff := deep.Equal(actual, tt.ex); diff != nil {
+			t.Error(diff)
+		}
+	}
+}
+
+func TestParseFloat64Slice(t *testing.T) {
+	tests := []struct {
+		s     string
+		ex    []float64
+		exErr bool
+	}{
+		{"1.5,2, 3.25", []float64{1.5, 2, 3.25}, false},
+		{"", []float64{}, false},
+		{"1.5,two", []float64{}, true},
+	}
+
+	for _, tt := range tests {
+		actual, err := cliutil.ParseFloat64Slice(tt.s)
+		if (err != nil) != tt.exErr {
+			t.Errorf("got error %v, expected error %t", err, tt.exErr)
+		}
+		if diff := deep.Equal(actual, tt.ex); diff != nil {
+			t.Error(diff)
+		}
+	}
+}
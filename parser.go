@@ -0,0 +1,144 @@
+package cliutil
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// ParseFunc is a definition for functions that parse an option's string value
+// into a value of the option's type.
+type ParseFunc func(s string) (interface{}, error)
+
+var (
+	parsersMu sync.RWMutex
+	parsers   = make(map[reflect.Type]ParseFunc)
+)
+
+// RegisterParser registers a function that parses options of type T, so that
+// fields of type T or *T can be options without implementing an OptionType.
+// Parsers take precedence over the pflag.Value and encoding.TextUnmarshaler
+// implementations of T.
+func RegisterParser[T any](parse func(s string) (T, error)) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+	parsers[t] = func(s string) (interface{}, error) { return parse(s) }
+}
+
+func init() {
+	RegisterParser(func(s string) (url.URL, error) {
+		u, err := url.Parse(s)
+		if err != nil {
+			return url.URL{}, err
+		}
+		return *u, nil
+	})
+}
+
+var (
+	pflagValueType      = reflect.TypeOf((*pflag.Value)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// parserFor returns the ParseFunc for options of type t, which is either
+// registered via RegisterParser or uses the pflag.Value or
+// encoding.TextUnmarshaler implementation of *t. False is returned if there
+// is none.
+func parserFor(t reflect.Type) (ParseFunc, bool) {
+	parsersMu.RLock()
+	parse, ok := parsers[t]
+	parsersMu.RUnlock()
+	if ok {
+		return parse, true
+	}
+
+	ptr := reflect.PtrTo(t)
+	switch {
+	case ptr.Implements(pflagValueType):
+		return func(s string) (interface{}, error) {
+			v := reflect.New(t)
+			err := v.Interface().(pflag.Value).Set(s)
+			return v.Elem().Interface(), err
+		}, true
+	case ptr.Implements(textUnmarshalerType):
+		return func(s string) (interface{}, error) {
+			v := reflect.New(t)
+			err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+			return v.Elem().Interface(), err
+		}, true
+	}
+	return nil, false
+}
+
+// ParserOption implements Option for options whose type has a ParseFunc,
+// i.e., types registered via RegisterParser and types implementing
+// pflag.Value or encoding.TextUnmarshaler.
+type ParserOption struct {
+	tag   map[string]string
+	typ   reflect.Type
+	parse ParseFunc
+}
+
+// newParserOption returns a *ParserOption for type t.
+func newParserOption(tag map[string]string, t reflect.Type, parse ParseFunc) OptionType {
+	return &ParserOption{tag: tag, typ: t, parse: parse}
+}
+
+// Set implements OptionType.Set. Flag values are parsed when the flag is
+// set, so that invalid values are reported like those of built-in types.
+func (opt *ParserOption) Set(f *Flagger) error {
+	v := &parserValue{typ: opt.typ, parse: opt.parse}
+	if s, ok := opt.tag["default"]; ok {
+		if err := v.Set(s); err != nil {
+			return fmt.Errorf("invalid default: %w", err)
+		}
+	}
+	f.Var(v, opt.tag["option"], opt.tag["short"], opt.tag["usage"])
+	return nil
+}
+
+// Read implements OptionType.Read. Empty values leave the field's zero value.
+func (opt *ParserOption) Read(cfg *viper.Viper, field reflect.Value) error {
+	s := cfg.GetString(opt.tag["option"])
+	if s == "" {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+
+	v, err := opt.parse(s)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	field.Set(reflect.ValueOf(v))
+	return nil
+}
+
+// parserValue is a pflag.Value that validates values with a ParseFunc and
+// stores them as strings, which is how viper reads them.
+type parserValue struct {
+	typ   reflect.Type
+	parse ParseFunc
+	s     string
+}
+
+// String implements pflag.Value.String.
+func (v *parserValue) String() string { return v.s }
+
+// Set implements pflag.Value.Set.
+func (v *parserValue) Set(s string) error {
+	if _, err := v.parse(s); err != nil {
+		return err
+	}
+	v.s = s
+	return nil
+}
+
+// Type implements pflag.Value.Type.
+func (v *parserValue) Type() string { return strings.ToLower(v.typ.Name()) }
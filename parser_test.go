@@ -0,0 +1,88 @@
+package cliutil_test
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cpliakas/cliutil"
+	"github.com/spf13/viper"
+)
+
+type Level string
+
+func (l *Level) String() string { return string(*l) }
+func (l *Level) Type() string   { return "level" }
+func (l *Level) Set(s string) error {
+	switch s {
+	case "debug", "info", "error":
+		*l = Level(s)
+		return nil
+	}
+	return fmt.Errorf("invalid level %q", s)
+}
+
+type Celsius float64
+
+func init() {
+	cliutil.RegisterParser(func(s string) (Celsius, error) {
+		f, err := strconv.ParseFloat(strings.TrimSuffix(s, "C"), 64)
+		return Celsius(f), err
+	})
+}
+
+type ParserInput struct {
+	Addr     net.IP   `cliutil:"option=addr default=127.0.0.1"`
+	Endpoint url.URL  `cliutil:"option=endpoint"`
+	Proxy    *url.URL `cliutil:"option=proxy"`
+	Level    Level    `cliutil:"option=level default=info"`
+	Temp     Celsius  `cliutil:"option=temp"`
+}
+
+func TestReadOptionsParsers(t *testing.T) {
+	input := &ParserInput{}
+	cmd := newTestCommand(t, viper.New(), input)
+	cmd.SetArgs([]string{"--endpoint", "https://example.com/api", "--level", "debug", "--temp", "21.5C"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if ex := net.ParseIP("127.0.0.1"); !input.Addr.Equal(ex) {
+		t.Errorf("addr: got %v, expected %v", input.Addr, ex)
+	}
+	if ex := "https://example.com/api"; input.Endpoint.String() != ex {
+		t.Errorf("endpoint: got %q, expected %q", input.Endpoint.String(), ex)
+	}
+	if input.Proxy != nil {
+		t.Errorf("proxy: got %v, expected nil", input.Proxy)
+	}
+	if ex := Level("debug"); input.Level != ex {
+		t.Errorf("level: got %q, expected %q", input.Level, ex)
+	}
+	if ex := Celsius(21.5); input.Temp != ex {
+		t.Errorf("temp: got %v, expected %v", input.Temp, ex)
+	}
+	if typ := cmd.Flags().Lookup("addr").Value.Type(); typ != "ip" {
+		t.Errorf("got flag type %q, expected %q", typ, "ip")
+	}
+}
+
+func TestReadOptionsParsersInvalid(t *testing.T) {
+	cmd := newTestCommand(t, viper.New(), &ParserInput{})
+	cmd.SetArgs([]string{"--level", "trace"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), `invalid level "trace"`) {
+		t.Errorf("got %v, expected invalid level error", err)
+	}
+
+	input := &ParserInput{}
+	v := viper.New()
+	newTestCommand(t, v, input)
+	v.Set("addr", "not-an-ip")
+	if err := cliutil.ReadOptions(input, v); !errors.Is(err, cliutil.ErrInvalid) {
+		t.Errorf("got %v, expected %v", err, cliutil.ErrInvalid)
+	}
+}
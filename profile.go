@@ -0,0 +1,160 @@
+package cliutil
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// OptionProfile is the name of the option that selects a profile.
+const OptionProfile = "profile"
+
+// ProfilesKey is the config file key that contains the profiles. Each profile
+// is a section keyed by the profile's name, e.g., profiles.prod.region.
+const ProfilesKey = "profiles"
+
+// ErrProfileNotFound is returned when selecting a profile that isn't defined
+// in the config files.
+var ErrProfileNotFound = errors.New("profile not found")
+
+// ProfileOption adds the persistent --profile option that selects a profile.
+// The profile can also be selected via the <PREFIX>_PROFILE environment
+// variable or the profile key of a config file.
+func (f *Flagger) ProfileOption() {
+	f.PersistentString(OptionProfile, "", "", "configuration profile to use")
+}
+
+// ListProfiles returns the sorted names of the profiles defined in cfg.
+func ListProfiles(cfg *viper.Viper) []string {
+	profiles := cfg.GetStringMap(ProfilesKey)
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SelectProfile applies the profile selected via the --profile option of cmd,
+// which is looked up so that it is found when defined on a parent command, or
+// via the <PREFIX>_PROFILE environment variable or config files of cfg. It is
+// a no-op if no profile is selected.
+func SelectProfile(cmd *cobra.Command, cfg *viper.Viper) error {
+	name := cfg.GetString(OptionProfile)
+	if flag := cmd.Flags().Lookup(OptionProfile); flag != nil && flag.Changed {
+		name = flag.Value.String()
+	}
+	return ApplyProfile(cfg, name)
+}
+
+// ApplyProfile merges the keys of the named profile into the configuration of
+// cfg. Profile values override config files and defaults but not flags or
+// environment variables. The profile is applied again whenever the config
+// files are reloaded. The config files loaded by LoadConfigFiles, or else the
+// file read by viper.ReadInConfig, are read again before applying a different
+// profile, so keys that are only set by the previous profile are removed. An
+// empty name removes the previous profile.
+func ApplyProfile(cfg *viper.Viper, name string) error {
+	key := ProfilesKey + "." + name
+	if name != "" {
+		if _, ok := cfg.GetStringMap(ProfilesKey)[strings.ToLower(name)]; !ok {
+			return NewConfigError(fmt.Errorf("%s: %w", name, ErrProfileNotFound))
+		}
+	}
+
+	cfgmetaMu.Lock()
+	meta := getConfigMeta(cfg)
+	prev, files := meta.profile, meta.files
+	cfgmetaMu.Unlock()
+
+	// Replace the previous profile with the values from the config files,
+	// falling back to the file read by viper if LoadConfigFiles wasn't used.
+	switch {
+	case prev == "":
+	case len(files) > 0:
+		sources, err := readConfigFiles(cfg, files)
+		if err != nil {
+			return err
+		}
+		cfgmetaMu.Lock()
+		meta.sources = sources
+		meta.profile = ""
+		cfgmetaMu.Unlock()
+	case cfg.ConfigFileUsed() != "":
+		if err := cfg.ReadInConfig(); err != nil {
+			return NewConfigError(fmt.Errorf("error reading config file %s: %w", cfg.ConfigFileUsed(), err))
+		}
+		cfgmetaMu.Lock()
+		meta.profile = ""
+		cfgmetaMu.Unlock()
+	}
+
+	if name == "" {
+		return nil
+	}
+
+	if err := cfg.MergeConfigMap(cfg.GetStringMap(key)); err != nil {
+		return NewConfigError(fmt.Errorf("error applying profile %s: %w", name, err))
+	}
+
+	cfgmetaMu.Lock()
+	defer cfgmetaMu.Unlock()
+	meta.profile = name
+
+	// Report the file that defined the profile as the source of its keys.
+	prefix := strings.ToLower(key) + "."
+	for k, f := range meta.sources {
+		if strings.HasPrefix(k, prefix) {
+			meta.sources[strings.TrimPrefix(k, prefix)] = f
+		}
+	}
+
+	return nil
+}
+
+// ActiveProfile returns the name of the profile applied to cfg.
+func ActiveProfile(cfg *viper.Viper) string {
+	cfgmetaMu.RLock()
+	defer cfgmetaMu.RUnlock()
+	if meta, ok := lookupConfigMeta(cfg); ok {
+		return meta.profile
+	}
+	return ""
+}
+
+// ReadProfileOptions reads the effective options for the named profile into
+// a without changing cfg. Values are resolved from the flags of cmd, if cmd
+// is not nil, environment variables, the profile, config files and defaults
+// in order of precedence.
+func ReadProfileOptions(cmd *cobra.Command, cfg *viper.Viper, name string, a interface{}) error {
+	cfgmetaMu.RLock()
+	meta := configMeta{}
+	if m, ok := lookupConfigMeta(cfg); ok {
+		meta = *m
+	}
+	cfgmetaMu.RUnlock()
+
+	v := viper.New()
+	if meta.hasEnv {
+		v = InitConfig(meta.envPrefix)
+	}
+	if cmd != nil {
+		if err := v.BindPFlags(cmd.Flags()); err != nil {
+			return err
+		}
+	}
+	if len(meta.files) > 0 {
+		if err := LoadConfigFiles(v, meta.files...); err != nil {
+			return err
+		}
+	}
+	if err := ApplyProfile(v, name); err != nil {
+		return err
+	}
+
+	return ReadOptions(a, v)
+}
@@ -0,0 +1,208 @@
+package cliutil_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/cpliakas/cliutil"
+	"github.com/go-test/deep"
+	"github.com/spf13/cobra"
+)
+
+type ProfileInput struct {
+	Region   string `cliutil:"option=region default=us-east-1"`
+	Endpoint string `cliutil:"option=endpoint"`
+	Debug    bool   `cliutil:"option=debug"`
+}
+
+const profileConfig = `
+endpoint: https://api.example.com
+profiles:
+  dev:
+    endpoint: https://dev.example.com
+    debug: true
+  prod:
+    region: eu-west-1
+`
+
+func TestSelectProfile(t *testing.T) {
+	name := tempConfig(t, profileConfig)
+	defer os.Remove(name)
+
+	rootCmd := &cobra.Command{Use: "root"}
+	cliutil.NewFlagger(rootCmd, cliutil.InitConfig("CLIUTIL_TEST")).ProfileOption()
+
+	v := cliutil.InitConfig("CLIUTIL_TEST")
+	cmd := newTestCommand(t, v, &ProfileInput{})
+	rootCmd.AddCommand(cmd)
+	if err := cliutil.LoadConfigFiles(v, name); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := deep.Equal(cliutil.ListProfiles(v), []string{"dev", "prod"}); diff != nil {
+		t.Error(diff)
+	}
+
+	// The --profile option is inherited from the parent command, and explicit
+	// flags still take precedence over the profile.
+	cmd.Flags().AddFlagSet(rootCmd.PersistentFlags())
+	if err := cmd.ParseFlags([]string{"--profile", "dev", "--debug=false"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cliutil.SelectProfile(cmd, v); err != nil {
+		t.Fatal(err)
+	}
+	if actual := cliutil.ActiveProfile(v); actual != "dev" {
+		t.Errorf("got %q, expected %q", actual, "dev")
+	}
+
+	input := &ProfileInput{}
+	if err := cliutil.ReadOptions(input, v); err != nil {
+		t.Fatal(err)
+	}
+
+	ex := &ProfileInput{Region: "us-east-1", Endpoint: "https://dev.example.com", Debug: false}
+	if diff := deep.Equal(input, ex); diff != nil {
+		t.Error(diff)
+	}
+
+	if actual := cliutil.ConfigSource(v, "endpoint"); actual != name {
+		t.Errorf("got %q, expected %q", actual, name)
+	}
+}
+
+func TestSelectProfileEnv(t *testing.T) {
+	os.Setenv("CLIUTIL_TEST_PROFILE", "prod")
+	defer os.Unsetenv("CLIUTIL_TEST_PROFILE")
+
+	name := tempConfig(t, profileConfig)
+	defer os.Remove(name)
+
+	rootCmd := &cobra.Command{Use: "root"}
+	cliutil.NewFlagger(rootCmd, cliutil.InitConfig("CLIUTIL_TEST")).ProfileOption()
+
+	v := cliutil.InitConfig("CLIUTIL_TEST")
+	cmd := newTestCommand(t, v, &ProfileInput{})
+	rootCmd.AddCommand(cmd)
+	if err := cliutil.LoadConfigFiles(v, name); err != nil {
+		t.Fatal(err)
+	}
+	if err := cliutil.SelectProfile(cmd, v); err != nil {
+		t.Fatal(err)
+	}
+
+	input := &ProfileInput{}
+	if err := cliutil.ReadOptions(input, v); err != nil {
+		t.Fatal(err)
+	}
+
+	ex := &ProfileInput{Region: "eu-west-1", Endpoint: "https://api.example.com"}
+	if diff := deep.Equal(input, ex); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestReadProfileOptions(t *testing.T) {
+	name := tempConfig(t, profileConfig)
+	defer os.Remove(name)
+
+	rootCmd := &cobra.Command{Use: "root"}
+	cliutil.NewFlagger(rootCmd, cliutil.InitConfig("CLIUTIL_TEST")).ProfileOption()
+
+	v := cliutil.InitConfig("CLIUTIL_TEST")
+	cmd := newTestCommand(t, v, &ProfileInput{})
+	rootCmd.AddCommand(cmd)
+	if err := cliutil.LoadConfigFiles(v, name); err != nil {
+		t.Fatal(err)
+	}
+
+	input := &ProfileInput{}
+	if err := cliutil.ReadProfileOptions(cmd, v, "prod", input); err != nil {
+		t.Fatal(err)
+	}
+
+	ex := &ProfileInput{Region: "eu-west-1", Endpoint: "https://api.example.com"}
+	if diff := deep.Equal(input, ex); diff != nil {
+		t.Error(diff)
+	}
+
+	// The configuration passed to the function is not changed.
+	if actual := v.GetString("region"); actual != "us-east-1" {
+		t.Errorf("got %q, expected %q", actual, "us-east-1")
+	}
+
+	err := cliutil.ReadProfileOptions(cmd, v, "missing", input)
+	if !errors.Is(err, cliutil.ErrProfileNotFound) {
+		t.Errorf("got %v, expected %v", err, cliutil.ErrProfileNotFound)
+	}
+}
+
+func TestApplyProfileSwitch(t *testing.T) {
+	name := tempConfig(t, profileConfig)
+	defer os.Remove(name)
+
+	rootCmd := &cobra.Command{Use: "root"}
+	cliutil.NewFlagger(rootCmd, cliutil.InitConfig("CLIUTIL_TEST")).ProfileOption()
+
+	v := cliutil.InitConfig("CLIUTIL_TEST")
+	cmd := newTestCommand(t, v, &ProfileInput{})
+	rootCmd.AddCommand(cmd)
+	if err := cliutil.LoadConfigFiles(v, name); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		profile string
+		ex      *ProfileInput
+	}{
+		{"dev", &ProfileInput{Region: "us-east-1", Endpoint: "https://dev.example.com", Debug: true}},
+		{"prod", &ProfileInput{Region: "eu-west-1", Endpoint: "https://api.example.com"}},
+		{"", &ProfileInput{Region: "us-east-1", Endpoint: "https://api.example.com"}},
+	}
+
+	for _, tt := range tests {
+		if err := cliutil.ApplyProfile(v, tt.profile); err != nil {
+			t.Fatal(err)
+		}
+		if actual := cliutil.ActiveProfile(v); actual != tt.profile {
+			t.Errorf("got %q, expected %q", actual, tt.profile)
+		}
+
+		input := &ProfileInput{}
+		if err := cliutil.ReadOptions(input, v); err != nil {
+			t.Fatal(err)
+		}
+		if diff := deep.Equal(input, tt.ex); diff != nil {
+			t.Errorf("%q: %v", tt.profile, diff)
+		}
+	}
+}
+
+func TestApplyProfileSwitchReadInConfig(t *testing.T) {
+	name := tempConfig(t, profileConfig)
+	defer os.Remove(name)
+
+	v := cliutil.InitConfig("CLIUTIL_TEST")
+	newTestCommand(t, v, &ProfileInput{})
+	v.SetConfigFile(name)
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, profile := range []string{"dev", "prod"} {
+		if err := cliutil.ApplyProfile(v, profile); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	input := &ProfileInput{}
+	if err := cliutil.ReadOptions(input, v); err != nil {
+		t.Fatal(err)
+	}
+	ex := &ProfileInput{Region: "eu-west-1", Endpoint: "https://api.example.com"}
+	if diff := deep.Equal(input, ex); diff != nil {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,185 @@
+package cliutil
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+
+	"github.com/spf13/viper"
+)
+
+// Source* constants contain the sources an option's value can come from in
+// order of precedence.
+const (
+	SourceArg     = "arg"
+	SourceFlag    = "flag"
+	SourceEnv     = "env"
+	SourceConfig  = "config"
+	SourceFile    = "file"
+	SourceDefault = "default"
+)
+
+// OptionShowConfig is the name of the option that shows the resolved values
+// of options and their sources instead of running the command.
+const OptionShowConfig = "show-config"
+
+// OptionValue is the resolved value of an option and where it came from.
+type OptionValue struct {
+	Option string      `json:"option" yaml:"option" cliutil:"column=OPTION"`
+	Value  interface{} `json:"value" yaml:"value" cliutil:"column=VALUE"`
+	Source string      `json:"source" yaml:"source" cliutil:"column=SOURCE"`
+
+	// Detail identifies the source, e.g., the environment variable or the
+	// config file that set the option.
+	Detail string `json:"detail,omitempty" yaml:"detail,omitempty" cliutil:"column=DETAIL"`
+}
+
+// OptionValues returns the value and source of every option in a, which
+// should have been read by ReadOptions. Values of secrets are redacted, and
+// options with func=ioreader or func=stdin report the value they were read
+// from rather than the data.
+func OptionValues(a interface{}, cfg *viper.Viper) ([]OptionValue, error) {
+	rv, rt, err := resolveStruct(a)
+	if err != nil {
+		return nil, err
+	}
+	var values []OptionValue
+	optionValues(rv, rt, cfg, "", &values)
+	return values, nil
+}
+
+func optionValues(rv reflect.Value, rt reflect.Type, cfg *viper.Viper, prefix string, values *[]OptionValue) {
+	for idx := 0; idx < rt.NumField(); idx++ {
+		rvf, rtf, skip := resolveField(rv, rt, idx)
+		if skip {
+			continue
+		}
+
+		if rvf.Kind() == reflect.Struct && !isOption(rtf) {
+			optionValues(rvf, rvf.Type(), cfg, nestedPrefix(prefix, rtf), values)
+			continue
+		}
+
+		tag, ok := parseOptionTag(rtf)
+		if !ok {
+			continue
+		}
+		tag = prefixOption(prefix, tag)
+
+		// Report nil pointers as unset.
+		var value interface{}
+		if f := rv.Field(idx); f.Kind() != reflect.Ptr || !f.IsNil() {
+			value = rvf.Interface()
+		}
+		switch tag["func"] {
+		case "ioreader", "stdin":
+			value = cfg.GetString(tag["option"])
+		}
+		if tagBool(tag, "secret") && value != nil && !reflect.ValueOf(value).IsZero() {
+			value = RedactedValue
+		}
+
+		source, detail := optionSource(cfg, tag)
+		*values = append(*values, OptionValue{
+			Option: tag["option"],
+			Value:  value,
+			Source: source,
+			Detail: detail,
+		})
+	}
+}
+
+// optionSource returns where the value of the option described by tag came
+// from, following viper's order of precedence.
+func optionSource(cfg *viper.Viper, tag map[string]string) (source, detail string) {
+	name := tag["option"]
+
+	cfgmetaMu.RLock()
+	var arg bool
+	var cmdFlag func() (string, bool)
+	if meta, ok := lookupConfigMeta(cfg); ok {
+		arg = meta.args[name]
+		if cmd := meta.cmd.Value(); cmd != nil {
+			cmdFlag = func() (string, bool) {
+				flag := cmd.Flag(name)
+				return "--" + name, flag != nil && flag.Changed
+			}
+		}
+	}
+	cfgmetaMu.RUnlock()
+
+	if arg {
+		return SourceArg, ""
+	}
+	if cmdFlag != nil {
+		if detail, ok := cmdFlag(); ok {
+			return SourceFlag, detail
+		}
+	}
+	for _, env := range EnvVars(cfg, name) {
+		if v, ok := os.LookupEnv(env); ok && v != "" {
+			return SourceEnv, env
+		}
+	}
+	if file := ConfigSource(cfg, name); file != "" {
+		return SourceConfig, file
+	}
+	if cfg.InConfig(name) {
+		return SourceConfig, cfg.ConfigFileUsed()
+	}
+	if tagBool(tag, "secret") {
+		if path := cfg.GetString(secretFileOption(name)); path != "" {
+			return SourceFile, path
+		}
+	}
+	return SourceDefault, ""
+}
+
+// ShowConfigOption adds the hidden --show-config option that is handled by
+// ShowConfig. It is persistent so that it can be added to the root command.
+func (f *Flagger) ShowConfigOption() {
+	f.PersistentBool(OptionShowConfig, "", false, "show the resolved configuration and exit")
+	f.cmd.PersistentFlags().MarkHidden(OptionShowConfig)
+}
+
+// ShowConfig renders the values and sources of the options in a to w if the
+// --show-config option is set and returns true, in which case the command
+// should exit. The options are read from cfg into a new value of a's type
+// without checking required options or validation rules, so ShowConfig should
+// be called before ReadOptions to show invalid configurations, too. Options
+// with func=ioreader or func=stdin are not read. The output format and query
+// are read from the --output and --query options if set, and the format
+// defaults to a table.
+func ShowConfig(w io.Writer, a interface{}, cfg *viper.Viper) (bool, error) {
+	bindInheritedFlags(cfg)
+	if !cfg.GetBool(OptionShowConfig) {
+		return false, nil
+	}
+
+	_, rt, err := resolveStruct(a)
+	if err != nil {
+		return true, err
+	}
+	bindEnvAliases(cfg)
+
+	show := reflect.New(rt)
+	st := &readState{names: make(map[string]bool), show: true}
+	if _, err := readOptions(show.Elem(), rt, cfg, "", st); err != nil {
+		return true, err
+	}
+
+	values, err := OptionValues(show.Interface(), cfg)
+	if err != nil {
+		return true, err
+	}
+
+	format := cfg.GetString(OptionOutput)
+	if format == "" {
+		format = OutputTable
+	}
+	if err := Render(w, values, format, cfg.GetString(OptionQuery)); err != nil {
+		return true, fmt.Errorf("error showing config: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,148 @@
+package cliutil_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cpliakas/cliutil"
+	"github.com/go-test/deep"
+	"github.com/spf13/cobra"
+)
+
+type ProvenanceInput struct {
+	Target  string `cliutil:"option=target arg=0"`
+	Name    string `cliutil:"option=name"`
+	Region  string `cliutil:"option=region"`
+	Workers int    `cliutil:"option=workers"`
+	Level   string `cliutil:"option=level default=info"`
+	Token   string `cliutil:"option=token secret"`
+}
+
+func TestOptionValues(t *testing.T) {
+	tmp, err := ioutil.TempDir(os.TempDir(), "cliutil-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	file := filepath.Join(tmp, "config.yaml")
+	writeConfig(t, file, "workers: 4\n")
+
+	os.Setenv("CLIUTIL_TEST_REGION", "eu")
+	os.Setenv("CLIUTIL_TEST_TOKEN", "s3cr3t")
+	defer os.Unsetenv("CLIUTIL_TEST_REGION")
+	defer os.Unsetenv("CLIUTIL_TEST_TOKEN")
+
+	input := &ProvenanceInput{}
+	v := cliutil.InitConfig("CLIUTIL_TEST")
+	if err := cliutil.LoadConfigFiles(v, file); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	cmd := &cobra.Command{
+		Use: "test",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if shown, err := cliutil.ShowConfig(&out, input, v); shown || err != nil {
+				return err
+			}
+			return cliutil.ReadOptions(input, v)
+		},
+	}
+	flags := cliutil.NewFlagger(cmd, v)
+	flags.ShowConfigOption()
+	if err := flags.SetOptions(input); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd.SetArgs([]string{"prod", "--name", "app"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := cliutil.OptionValues(input, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex := []cliutil.OptionValue{
+		{Option: "target", Value: "prod", Source: cliutil.SourceArg},
+		{Option: "name", Value: "app", Source: cliutil.SourceFlag, Detail: "--name"},
+		{Option: "region", Value: "eu", Source: cliutil.SourceEnv, Detail: "CLIUTIL_TEST_REGION"},
+		{Option: "workers", Value: 4, Source: cliutil.SourceConfig, Detail: file},
+		{Option: "level", Value: "info", Source: cliutil.SourceDefault},
+		{Option: "token", Value: cliutil.RedactedValue, Source: cliutil.SourceEnv, Detail: "CLIUTIL_TEST_TOKEN"},
+	}
+	if diff := deep.Equal(values, ex); diff != nil {
+		t.Error(diff)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected no output without --show-config, got %q", out.String())
+	}
+
+	cmd.SetArgs([]string{"prod", "--name", "app", "--show-config"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"OPTION", "SOURCE", "CLIUTIL_TEST_REGION", cliutil.RedactedValue} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("expected output to contain %q, got:\n%s", s, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "s3cr3t") {
+		t.Errorf("expected secret to be redacted, got:\n%s", out.String())
+	}
+	if cmd.PersistentFlags().Lookup(cliutil.OptionShowConfig).Hidden != true {
+		t.Error("expected --show-config to be hidden")
+	}
+}
+
+type ShowConfigInvalidInput struct {
+	Token   string `cliutil:"option=token required"`
+	Workers int    `cliutil:"option=workers min=1"`
+	Level   string `cliutil:"option=level default=info"`
+}
+
+func TestShowConfigInvalid(t *testing.T) {
+	input := &ShowConfigInvalidInput{}
+	v := cliutil.InitConfig("CLIUTIL_TEST")
+
+	var out bytes.Buffer
+	cmd := &cobra.Command{
+		Use:           "test",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if shown, err := cliutil.ShowConfig(&out, input, v); shown || err != nil {
+				return err
+			}
+			return cliutil.ReadOptions(input, v)
+		},
+	}
+	flags := cliutil.NewFlagger(cmd, v)
+	flags.ShowConfigOption()
+	flags.OutputOptions(cliutil.OutputTable)
+	if err := flags.SetOptions(input); err != nil {
+		t.Fatal(err)
+	}
+
+	// The missing required option and the invalid value don't prevent the
+	// configuration from being shown.
+	cmd.SetArgs([]string{"--workers", "0", "--show-config", "--output", "json"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`"option": "token"`, `"value": 0,`, `"source": "flag"`, `"value": "info"`} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("expected output to contain %q, got:\n%s", s, out.String())
+		}
+	}
+
+	cmd.SetArgs([]string{"--workers", "0", "--show-config=false"})
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected error without --show-config")
+	}
+}
@@ -0,0 +1,109 @@
+package cliutil
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// ReloadFunc is a definition for functions that are notified when options
+// are reloaded. If err is not nil, opts contains the previous options.
+type ReloadFunc func(opts interface{}, err error)
+
+// Reloader re-reads the configuration into a fresh options struct and
+// notifies subscribers, e.g., when a long-running process receives SIGHUP.
+// The previous options are kept if the new ones cannot be read or are not
+// valid.
+type Reloader struct {
+	cfg     *viper.Viper
+	newOpts func() interface{}
+
+	// reloadMu serializes reloads, which read into the shared cfg.
+	reloadMu sync.Mutex
+
+	mu          sync.RWMutex
+	opts        interface{}
+	subscribers []ReloadFunc
+}
+
+// NewReloader returns a *Reloader that reads options from cfg into the
+// structs returned by newOpts, e.g., func() interface{} { return &Opts{} }.
+// The options are read once so that Reloader.Options is populated.
+func NewReloader(cfg *viper.Viper, newOpts func() interface{}) (*Reloader, error) {
+	r := &Reloader{cfg: cfg, newOpts: newOpts}
+
+	opts := newOpts()
+	if err := ReadOptions(opts, cfg); err != nil {
+		return nil, err
+	}
+	r.opts = opts
+
+	return r, nil
+}
+
+// Options returns the current options.
+func (r *Reloader) Options() interface{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.opts
+}
+
+// Subscribe registers a function that is called after every reload.
+func (r *Reloader) Subscribe(fn ReloadFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers = append(r.subscribers, fn)
+}
+
+// Reload re-reads the config files, if any are used, and reads the options
+// into a fresh struct. The new options replace the current ones only if they
+// were read without errors. Subscribers are notified either way. Concurrent
+// reloads run one after another, so the options of the last reload win.
+func (r *Reloader) Reload() error {
+	r.reloadMu.Lock()
+	opts, err := r.read()
+
+	r.mu.Lock()
+	if err == nil {
+		r.opts = opts
+	} else {
+		opts = r.opts
+	}
+	subscribers := make([]ReloadFunc, len(r.subscribers))
+	copy(subscribers, r.subscribers)
+	r.mu.Unlock()
+	r.reloadMu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(opts, err)
+	}
+
+	return err
+}
+
+func (r *Reloader) read() (interface{}, error) {
+	if files := ConfigFilesUsed(r.cfg); len(files) > 0 {
+		if err := LoadConfigFiles(r.cfg, files...); err != nil {
+			return nil, err
+		}
+	} else if r.cfg.ConfigFileUsed() != "" {
+		if err := r.cfg.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("error reading config file: %w", err)
+		}
+	}
+
+	opts := r.newOpts()
+	if err := ReadOptions(opts, r.cfg); err != nil {
+		return nil, err
+	}
+
+	return opts, nil
+}
+
+// WatchConfig reloads the options whenever the config file changes.
+func (r *Reloader) WatchConfig() {
+	r.cfg.OnConfigChange(func(fsnotify.Event) { r.Reload() })
+	r.cfg.WatchConfig()
+}
@@ -0,0 +1,40 @@
+// +build !windows
+
+package cliutil_test
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/cpliakas/cliutil"
+)
+
+func TestEventListenerReload(t *testing.T) {
+	r, name := newTestReloader(t)
+	defer os.Remove(name)
+
+	reloaded := make(chan int, 1)
+	r.Subscribe(func(opts interface{}, err error) {
+		reloaded <- opts.(*ReloadInput).Workers
+	})
+
+	e := cliutil.NewEventListener().Run()
+	defer e.StopSignal()
+	e.OnReload(func() { r.Reload() })
+
+	writeConfig(t, name, "workers: 8\n")
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case workers := <-reloaded:
+		if workers != 8 {
+			t.Errorf("got %v, expected %v", workers, 8)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout waiting for reload")
+	}
+}
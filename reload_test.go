@@ -0,0 +1,92 @@
+package cliutil_test
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/cpliakas/cliutil"
+	"github.com/spf13/viper"
+)
+
+type ReloadInput struct {
+	Workers int `cliutil:"option=workers default=1 min=1"`
+}
+
+func newTestReloader(t *testing.T) (*cliutil.Reloader, string) {
+	name := tempConfig(t, "workers: 2\n")
+
+	v := viper.New()
+	v.SetConfigFile(name)
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := cliutil.NewReloader(v, func() interface{} { return &ReloadInput{} })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return r, name
+}
+
+func TestReloader(t *testing.T) {
+	r, name := newTestReloader(t)
+	defer os.Remove(name)
+
+	if actual := r.Options().(*ReloadInput).Workers; actual != 2 {
+		t.Errorf("got %v, expected %v", actual, 2)
+	}
+
+	var notified *ReloadInput
+	var notifiedErr error
+	r.Subscribe(func(opts interface{}, err error) {
+		notified, notifiedErr = opts.(*ReloadInput), err
+	})
+
+	// Valid options replace the current ones.
+	writeConfig(t, name, "workers: 4\n")
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if actual := r.Options().(*ReloadInput).Workers; actual != 4 {
+		t.Errorf("got %v, expected %v", actual, 4)
+	}
+	if notified == nil || notified.Workers != 4 || notifiedErr != nil {
+		t.Errorf("got %+v and %v, expected workers 4 and nil", notified, notifiedErr)
+	}
+
+	// Invalid options keep the previous ones.
+	writeConfig(t, name, "workers: 0\n")
+	if err := r.Reload(); !errors.Is(err, cliutil.ErrInvalid) {
+		t.Errorf("got %v, expected %v", err, cliutil.ErrInvalid)
+	}
+	if actual := r.Options().(*ReloadInput).Workers; actual != 4 {
+		t.Errorf("got %v, expected %v", actual, 4)
+	}
+	if notified.Workers != 4 || !errors.Is(notifiedErr, cliutil.ErrInvalid) {
+		t.Errorf("got %+v and %v, expected workers 4 and %v", notified, notifiedErr, cliutil.ErrInvalid)
+	}
+}
+
+func TestReloaderConcurrent(t *testing.T) {
+	r, name := newTestReloader(t)
+	defer os.Remove(name)
+
+	var wg sync.WaitGroup
+	for idx := 0; idx < 10; idx++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := r.Reload(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if actual := r.Options().(*ReloadInput).Workers; actual != 2 {
+		t.Errorf("got %v, expected %v", actual, 2)
+	}
+}
@@ -1,21 +1,32 @@
 package cliutil
 
 import (
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
 
 	jmespath "github.com/jmespath/go-jmespath"
+	"github.com/spf13/viper"
+	yaml "gopkg.in/yaml.v2"
 )
 
-// FormatJSON returns pretty-printed JSON as a string.
+// FormatJSON returns pretty-printed JSON as a string. Secrets are redacted.
 func FormatJSON(v interface{}) (string, error) {
-	b, err := json.MarshalIndent(v, "", "    ")
+	b, err := json.MarshalIndent(Redact(v), "", "    ")
 	return string(b), err
 }
 
 // FormatJSONWithFilter applies a JMESPath filter and returns pretty-printed
 // JSON as a string and panics on any marshal errors.
 func FormatJSONWithFilter(v interface{}, filter string) (out string, err error) {
+	v = Redact(v)
 	if filter != "" {
 		if v, err = jmespath.Search(filter, v); err != nil {
 			return
@@ -41,3 +52,362 @@ func PrintJSONWithFilter(v interface{}, filter string) error {
 	fmt.Println(s)
 	return err
 }
+
+// Output* constants contain the output formats that are registered by default.
+const (
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+	OutputTable = "table"
+	OutputWide  = "wide"
+	OutputCSV   = "csv"
+	OutputTSV   = "tsv"
+)
+
+// Option* constants contain the names of the options added by
+// Flagger.OutputOptions.
+const (
+	OptionOutput = "output"
+	OptionQuery  = "query"
+)
+
+// ErrFormatNotSupported is returned when rendering an unregistered format.
+var ErrFormatNotSupported = errors.New("output format not supported")
+
+// ErrDuplicateColumn is returned when two struct fields render the same
+// column, e.g., because they have the same json name or column key.
+var ErrDuplicateColumn = errors.New("duplicate column")
+
+// Renderer is implemented by types that write values in an output format.
+type Renderer interface {
+
+	// Render writes v to w.
+	Render(w io.Writer, v interface{}) error
+}
+
+// RendererFunc is an adapter that allows functions to be used as Renderers.
+type RendererFunc func(w io.Writer, v interface{}) error
+
+// Render implements Renderer.Render.
+func (fn RendererFunc) Render(w io.Writer, v interface{}) error { return fn(w, v) }
+
+var renderers map[string]Renderer
+
+// RegisterRenderer registers a Renderer by output format name.
+func RegisterRenderer(format string, r Renderer) { renderers[format] = r }
+
+// OutputFormats returns the names of the registered output formats.
+func OutputFormats() []string {
+	formats := make([]string, 0, len(renderers))
+	for format := range renderers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+func init() {
+	renderers = map[string]Renderer{
+		OutputJSON:  RendererFunc(renderJSON),
+		OutputYAML:  RendererFunc(renderYAML),
+		OutputTable: RendererFunc(func(w io.Writer, v interface{}) error { return renderTable(w, v, false) }),
+		OutputWide:  RendererFunc(func(w io.Writer, v interface{}) error { return renderTable(w, v, true) }),
+		OutputCSV:   RendererFunc(func(w io.Writer, v interface{}) error { return renderDelimited(w, v, ',') }),
+		OutputTSV:   RendererFunc(func(w io.Writer, v interface{}) error { return renderDelimited(w, v, '\t') }),
+	}
+}
+
+// Render applies a JMESPath filter to v, if one is passed, and writes the
+// result to w in the given format. Secrets are redacted before the filter is
+// applied.
+func Render(w io.Writer, v interface{}, format, filter string) (err error) {
+	r, ok := renderers[format]
+	if !ok {
+		return fmt.Errorf("%s: %w", format, ErrFormatNotSupported)
+	}
+	v = Redact(v)
+	if filter != "" {
+		if v, err = jmespath.Search(filter, v); err != nil {
+			return fmt.Errorf("error applying query: %w", err)
+		}
+	}
+	return r.Render(w, v)
+}
+
+// Print applies a JMESPath filter to v, if one is passed, and writes the
+// result to STDOUT in the given format.
+func Print(v interface{}, format, filter string) error {
+	return Render(os.Stdout, v, format, filter)
+}
+
+// PrintWithConfig writes v to STDOUT using the format and filter passed via
+// the options added by Flagger.OutputOptions.
+func PrintWithConfig(cfg *viper.Viper, v interface{}) error {
+	return Print(v, cfg.GetString(OptionOutput), cfg.GetString(OptionQuery))
+}
+
+// OutputOptions adds the --output and --query options that select the format
+// and JMESPath filter used by PrintWithConfig.
+func (f *Flagger) OutputOptions(format string) {
+	usage := fmt.Sprintf("output format, one of %s", strings.Join(OutputFormats(), ", "))
+	f.String(OptionOutput, "o", format, usage)
+	f.String(OptionQuery, "", "", "JMESPath query applied to the output")
+}
+
+func renderJSON(w io.Writer, v interface{}) error {
+	out, err := FormatJSON(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, out)
+	return err
+}
+
+// renderYAML round trips v through JSON so that json tags are honored.
+func renderYAML(w io.Writer, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var generic interface{}
+	if err := yaml.Unmarshal(b, &generic); err != nil {
+		return err
+	}
+
+	out, err := yaml.Marshal(generic)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+func renderTable(w io.Writer, v interface{}, wide bool) error {
+	header, rows, err := tabulate(v, wide)
+	if err != nil || len(header) == 0 {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func renderDelimited(w io.Writer, v interface{}, comma rune) error {
+	header, rows, err := tabulate(v, true)
+	if err != nil || len(header) == 0 {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	cw.Write(header)
+	cw.WriteAll(rows)
+	return cw.Error()
+}
+
+// column is a column derived from a struct field.
+type column struct {
+	header string
+	index  []int
+}
+
+// tabulate converts v into a header and rows. Slices render one row per
+// element, whereas all other values render a single row. Columns of structs
+// are selected via the column key of the cliutil tag, and columns with the
+// wide key are only included when wide is true. All exported fields are
+// rendered if no field has a column key. Maps render one column per key.
+func tabulate(v interface{}, wide bool) (header []string, rows [][]string, err error) {
+	rv := indirect(reflect.ValueOf(v))
+	if !rv.IsValid() {
+		return
+	}
+
+	var items []reflect.Value
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		for idx := 0; idx < rv.Len(); idx++ {
+			items = append(items, indirect(rv.Index(idx)))
+		}
+	} else {
+		items = []reflect.Value{rv}
+	}
+
+	// Determine the columns from the element type or the first item.
+	et := rv.Type()
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		et = et.Elem()
+	}
+	for et.Kind() == reflect.Ptr {
+		et = et.Elem()
+	}
+	if et.Kind() == reflect.Interface && len(items) > 0 && items[0].IsValid() {
+		et = items[0].Type()
+	}
+
+	switch et.Kind() {
+	case reflect.Struct:
+		var columns []column
+		if columns, err = structColumns(et, wide); err != nil {
+			return
+		}
+		for _, col := range columns {
+			header = append(header, col.header)
+		}
+		for _, item := range items {
+			row := make([]string, len(columns))
+			if item.IsValid() && item.Type() == et {
+				for idx, col := range columns {
+					row[idx] = formatCell(item.FieldByIndex(col.index))
+				}
+			}
+			rows = append(rows, row)
+		}
+
+	case reflect.Map:
+		keys := mapKeys(items)
+		for _, key := range keys {
+			header = append(header, strings.ToUpper(key))
+		}
+		for _, item := range items {
+			row := make([]string, len(keys))
+			if item.IsValid() && item.Kind() == reflect.Map {
+				for idx, key := range keys {
+					row[idx] = formatCell(item.MapIndex(reflect.ValueOf(key).Convert(item.Type().Key())))
+				}
+			}
+			rows = append(rows, row)
+		}
+
+	default:
+		header = []string{"VALUE"}
+		for _, item := range items {
+			rows = append(rows, []string{formatCell(item)})
+		}
+	}
+
+	return
+}
+
+// structColumns returns the columns of struct type t. An error is returned
+// if two fields render the same column.
+func structColumns(t reflect.Type, wide bool) ([]column, error) {
+	tagged := hasColumnTag(t)
+
+	var columns []column
+	fields := make(map[string]string)
+	for idx := 0; idx < t.NumField(); idx++ {
+		f := t.Field(idx)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		header := strings.ToUpper(name)
+		tag := parseTag(f)
+		if tagged {
+			s, ok := tag["column"]
+			if !ok {
+				continue
+			}
+			if s != "" {
+				header = s
+			}
+		}
+
+		// Check wide columns, too, so that duplicates don't depend on the
+		// output format.
+		if other, ok := fields[header]; ok {
+			return nil, fmt.Errorf("column %s: %w: set by fields %s and %s", header, ErrDuplicateColumn, other, f.Name)
+		}
+		fields[header] = f.Name
+
+		if tagged && tagBool(tag, "wide") && !wide {
+			continue
+		}
+		columns = append(columns, column{header: header, index: f.Index})
+	}
+
+	return columns, nil
+}
+
+// hasColumnTag returns true if any field of t has the column key.
+func hasColumnTag(t reflect.Type) bool {
+	for idx := 0; idx < t.NumField(); idx++ {
+		if _, ok := parseTag(t.Field(idx))["column"]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// mapKeys returns the sorted union of keys of the map items.
+func mapKeys(items []reflect.Value) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, item := range items {
+		if !item.IsValid() || item.Kind() != reflect.Map {
+			continue
+		}
+		for _, k := range item.MapKeys() {
+			key := fmt.Sprint(k.Interface())
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatCell formats a value for a single table or CSV cell. Slices are
+// joined with commas, and maps and structs are rendered as compact JSON.
+func formatCell(v reflect.Value) string {
+	v = indirect(v)
+	if !v.IsValid() {
+		return ""
+	}
+
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		parts := make([]string, v.Len())
+		for idx := range parts {
+			parts[idx] = formatCell(v.Index(idx))
+		}
+		return strings.Join(parts, ",")
+	case reflect.Map, reflect.Struct:
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return fmt.Sprint(v.Interface())
+		}
+		return string(b)
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// indirect dereferences pointers and interfaces until it reaches a concrete
+// value. An invalid reflect.Value is returned for nil values.
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
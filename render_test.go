@@ -1,7 +1,13 @@
 package cliutil_test
 
 import (
+	"errors"
+	"io/ioutil"
+	"testing"
+
 	"github.com/cpliakas/cliutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 type JsonData struct {
@@ -19,3 +25,115 @@ func ExamplePrintJSONWithFilter() {
 	cliutil.PrintJSONWithFilter(&JsonData{Data: "test"}, "data")
 	// Output: "test"
 }
+
+type Server struct {
+	Name   string   `json:"name" cliutil:"column=NAME"`
+	Status string   `json:"status" cliutil:"column=STATUS"`
+	Zone   string   `json:"zone" cliutil:"column=ZONE wide"`
+	Tags   []string `json:"tags"`
+}
+
+var servers = []*Server{
+	{Name: "web", Status: "running", Zone: "us-east-1a", Tags: []string{"a", "b"}},
+	{Name: "db", Status: "stopped", Zone: "us-east-1b"},
+}
+
+func ExamplePrint_table() {
+	cliutil.Print(servers, cliutil.OutputTable, "")
+	// Output:
+	// NAME   STATUS
+	// web    running
+	// db     stopped
+}
+
+func ExamplePrint_wide() {
+	cliutil.Print(servers, cliutil.OutputWide, "")
+	// Output:
+	// NAME   STATUS    ZONE
+	// web    running   us-east-1a
+	// db     stopped   us-east-1b
+}
+
+func ExamplePrint_csv() {
+	cliutil.Print(servers, cliutil.OutputCSV, "")
+	// Output:
+	// NAME,STATUS,ZONE
+	// web,running,us-east-1a
+	// db,stopped,us-east-1b
+}
+
+func ExamplePrint_tsv() {
+	cliutil.Print(&JsonData{Data: "test"}, cliutil.OutputTSV, "")
+	// Output:
+	// DATA
+	// test
+}
+
+func ExamplePrint_yaml() {
+	cliutil.Print(servers[0], cliutil.OutputYAML, "")
+	// Output:
+	// name: web
+	// status: running
+	// tags:
+	// - a
+	// - b
+	// zone: us-east-1a
+}
+
+func ExamplePrint_query() {
+	cliutil.Print(servers, cliutil.OutputTable, "[?Status=='running'].{name: Name, tags: Tags}")
+	// Output:
+	// NAME   TAGS
+	// web    a,b
+}
+
+func TestRenderFormatNotSupported(t *testing.T) {
+	err := cliutil.Render(ioutil.Discard, servers, "xml", "")
+	if !errors.Is(err, cliutil.ErrFormatNotSupported) {
+		t.Errorf("got %v, expected %v", err, cliutil.ErrFormatNotSupported)
+	}
+}
+
+type DuplicateJSONColumns struct {
+	ID    string `json:"id"`
+	Label string `json:"ID"`
+}
+
+type DuplicateTagColumns struct {
+	Name  string `cliutil:"column=NAME"`
+	Alias string `cliutil:"column=NAME wide"`
+}
+
+func TestRenderDuplicateColumn(t *testing.T) {
+	for _, v := range []interface{}{&DuplicateJSONColumns{}, &DuplicateTagColumns{}} {
+		for _, format := range []string{cliutil.OutputTable, cliutil.OutputCSV} {
+			err := cliutil.Render(ioutil.Discard, v, format, "")
+			if !errors.Is(err, cliutil.ErrDuplicateColumn) {
+				t.Errorf("%T %s: got %v, expected %v", v, format, err, cliutil.ErrDuplicateColumn)
+			}
+		}
+	}
+}
+
+func TestOutputOptions(t *testing.T) {
+	cmd := &cobra.Command{
+		Use:   "test",
+		Short: "test output options",
+		Run:   func(cmd *cobra.Command, args []string) {},
+	}
+
+	v := viper.New()
+	flags := cliutil.NewFlagger(cmd, v)
+	flags.OutputOptions(cliutil.OutputTable)
+
+	if err := cmd.ParseFlags([]string{"-o", "csv", "--query", "[0]"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if actual := v.GetString(cliutil.OptionOutput); actual != cliutil.OutputCSV {
+		t.Errorf("got %q, expected %q", actual, cliutil.OutputCSV)
+	}
+	if actual := v.GetString(cliutil.OptionQuery); actual != "[0]" {
+		t.Errorf("got %q, expected %q", actual, "[0]")
+	}
+}
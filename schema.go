@@ -0,0 +1,430 @@
+package cliutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// JSONSchemaVersion is the JSON Schema draft used by JSONSchema.
+const JSONSchemaVersion = "http://json-schema.org/draft-07/schema#"
+
+// OutputTOML is the TOML format for sample config files.
+const OutputTOML = "toml"
+
+// durationPattern matches the durations parsed by time.ParseDuration.
+const durationPattern = `^-?([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+
+// configOption is an option that can be set in config files.
+type configOption struct {
+	tag   map[string]string
+	typ   reflect.Type
+	value interface{}
+}
+
+// configOptions returns the options in a that can be set in config files,
+// i.e., all options except positional arguments, with their typed defaults.
+func configOptions(a interface{}) ([]configOption, error) {
+	rv, rt, err := resolveStruct(a)
+	if err != nil {
+		return nil, err
+	}
+	var opts []configOption
+	err = walkConfigOptions(rv, rt, "", &opts)
+	return opts, err
+}
+
+func walkConfigOptions(rv reflect.Value, rt reflect.Type, prefix string, opts *[]configOption) error {
+	for idx := 0; idx < rt.NumField(); idx++ {
+		rvf, rtf, skip := resolveField(rv, rt, idx)
+		if skip {
+			continue
+		}
+
+		if rvf.Kind() == reflect.Struct && !isOption(rtf) {
+			if err := walkConfigOptions(rvf, rvf.Type(), nestedPrefix(prefix, rtf), opts); err != nil {
+				return err
+			}
+			continue
+		}
+
+		tag, ok := parseOptionTag(rtf)
+		if !ok {
+			continue
+		}
+		tag = prefixOption(prefix, tag)
+		if _, ok := tag["arg"]; ok {
+			continue
+		}
+
+		value, err := defaultValue(tag, rvf)
+		if err != nil {
+			return fmt.Errorf("option %s: %w", tag["option"], err)
+		}
+		*opts = append(*opts, configOption{tag: tag, typ: rvf.Type(), value: value})
+	}
+	return nil
+}
+
+// defaultValue returns the option's default converted to the field's type,
+// the same way ReadOptions converts it. Options with func=ioreader or
+// func=stdin are read from a URI or STDIN, so their default is returned as a
+// string.
+func defaultValue(tag map[string]string, field reflect.Value) (interface{}, error) {
+	switch tag["func"] {
+	case "ioreader", "stdin", "boolstring":
+		return tag["default"], nil
+	}
+
+	opt, err := newOptionType(tag, field.Interface())
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := opt.(*ParserOption); ok {
+		return tag["default"], nil
+	}
+
+	cfg := viper.New()
+	if s, ok := tag["default"]; ok {
+		cfg.SetDefault(tag["option"], s)
+	}
+	v := reflect.New(field.Type()).Elem()
+	if err := opt.Read(cfg, v); err != nil {
+		return nil, err
+	}
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String(), nil
+	}
+	return v.Interface(), nil
+}
+
+// JSONSchema returns a JSON Schema document that describes config files
+// setting the options in a. Required options aren't required by the schema,
+// because they may also be set via flags or environment variables.
+func JSONSchema(a interface{}) (map[string]interface{}, error) {
+	opts, err := configOptions(a)
+	if err != nil {
+		return nil, err
+	}
+
+	props := make(map[string]interface{}, len(opts))
+	for _, opt := range opts {
+		prop, err := propertySchema(opt)
+		if err != nil {
+			return nil, fmt.Errorf("option %s: %w", opt.tag["option"], err)
+		}
+		props[opt.tag["option"]] = prop
+	}
+
+	return map[string]interface{}{
+		"$schema":    JSONSchemaVersion,
+		"type":       "object",
+		"properties": props,
+	}, nil
+}
+
+// WriteJSONSchema writes the JSONSchema of a to w as indented JSON.
+func WriteJSONSchema(w io.Writer, a interface{}) error {
+	schema, err := JSONSchema(a)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+// propertySchema returns the JSON Schema of an option.
+func propertySchema(opt configOption) (map[string]interface{}, error) {
+	schema := typeSchema(opt.tag, opt.typ)
+	if usage := opt.tag["usage"]; usage != "" {
+		schema["description"] = usage
+	}
+	if _, ok := opt.tag["default"]; ok && !tagBool(opt.tag, "secret") {
+		schema["default"] = opt.value
+	}
+
+	array := schema["type"] == "array"
+	isString := schema["type"] == "string" && opt.typ != reflect.TypeOf(time.Duration(0))
+	number := schema["type"] == "integer" || schema["type"] == "number"
+
+	for _, key := range []string{"len", "min", "max"} {
+		s, ok := opt.tag[key]
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			continue
+		}
+		switch {
+		case number && key == "min":
+			schema["minimum"] = n
+		case number && key == "max":
+			schema["maximum"] = n
+		case isString && key != "max":
+			schema["minLength"] = int(n)
+			if key == "len" {
+				schema["maxLength"] = int(n)
+			}
+		case isString:
+			schema["maxLength"] = int(n)
+		case array && key != "max":
+			schema["minItems"] = int(n)
+			if key == "len" {
+				schema["maxItems"] = int(n)
+			}
+		case array:
+			schema["maxItems"] = int(n)
+		}
+	}
+
+	// Enums and patterns apply to the elements of slices.
+	target := schema
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		target = items
+	}
+	for _, key := range []string{"oneof", "enum"} {
+		if s, ok := opt.tag[key]; ok {
+			values, err := ParseStringSlice(s)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			target["enum"] = enumValues(target["type"], values)
+		}
+	}
+	if s, ok := opt.tag["regex"]; ok && target["type"] == "string" {
+		target["pattern"] = s
+	}
+
+	return schema, nil
+}
+
+// typeSchema returns the JSON Schema of a field's type.
+func typeSchema(tag map[string]string, t reflect.Type) map[string]interface{} {
+	switch tag["func"] {
+	case "ioreader", "stdin":
+		return map[string]interface{}{"type": "string"}
+	case "boolstring":
+		return map[string]interface{}{"type": []string{"boolean", "string"}}
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return map[string]interface{}{"type": "string", "pattern": durationPattern}
+	}
+	if _, ok := parserFor(t); ok {
+		return map[string]interface{}{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(nil, t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(nil, t.Elem())}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// enumValues converts allowed values to the JSON Schema type.
+func enumValues(typ interface{}, values []string) []interface{} {
+	enum := make([]interface{}, len(values))
+	for idx, s := range values {
+		enum[idx] = s
+		switch typ {
+		case "integer", "number":
+			if n, err := strconv.ParseFloat(s, 64); err == nil {
+				enum[idx] = n
+			}
+		case "boolean":
+			if b, err := strconv.ParseBool(s); err == nil {
+				enum[idx] = b
+			}
+		}
+	}
+	return enum
+}
+
+// WriteSampleConfig writes a sample config file in the given format, i.e.,
+// yaml or toml, that lists the options in a with their usage, validation
+// rules and defaults. The options are commented out so that the file can be
+// used as a starting point without changing any values.
+func WriteSampleConfig(w io.Writer, a interface{}, format string) error {
+	opts, err := configOptions(a)
+	if err != nil {
+		return err
+	}
+
+	var encode func(key string, value interface{}) (string, error)
+	switch format {
+	case OutputYAML:
+		encode = yamlLine
+	case OutputTOML:
+		encode = tomlLine
+	default:
+		return fmt.Errorf("%s: %w", format, ErrFormatNotSupported)
+	}
+
+	var buf bytes.Buffer
+	for idx, opt := range opts {
+		if idx > 0 {
+			buf.WriteString("\n")
+		}
+		for _, line := range sampleComments(opt) {
+			fmt.Fprintf(&buf, "# %s\n", line)
+		}
+
+		value := opt.value
+		if tagBool(opt.tag, "secret") {
+			value = ""
+		}
+		line, err := encode(opt.tag["option"], value)
+		if err != nil {
+			return fmt.Errorf("option %s: %w", opt.tag["option"], err)
+		}
+		fmt.Fprintf(&buf, "# %s\n", line)
+	}
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// sampleComments returns the comments that describe an option in a sample
+// config file.
+func sampleComments(opt configOption) (lines []string) {
+	if usage := opt.tag["usage"]; usage != "" {
+		lines = append(lines, usage)
+	}
+	if tagBool(opt.tag, "required") {
+		lines = append(lines, "Required.")
+	}
+	var rules []string
+	for _, rule := range validationRules {
+		if param, ok := opt.tag[rule.key]; ok {
+			rules = append(rules, rule.key+"="+param)
+		}
+	}
+	if len(rules) > 0 {
+		lines = append(lines, "Validation: "+strings.Join(rules, ", "))
+	}
+	return
+}
+
+func yamlLine(key string, value interface{}) (string, error) {
+	b, err := yaml.Marshal(map[string]interface{}{key: value})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.Replace(string(b), "\n", "\n# ", -1), "\n# "), nil
+}
+
+func tomlLine(key string, value interface{}) (string, error) {
+	s, err := tomlValue(reflect.ValueOf(value))
+	if err != nil {
+		return "", err
+	}
+	return key + " = " + s, nil
+}
+
+// tomlValue encodes v as a TOML value. Maps are encoded as inline tables.
+func tomlValue(v reflect.Value) (string, error) {
+	switch v.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		s := strconv.FormatFloat(v.Float(), 'f', -1, 64)
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+		return s, nil
+	case reflect.String:
+		return tomlString(v.String()), nil
+	case reflect.Slice, reflect.Array:
+		elems := make([]string, v.Len())
+		for idx := range elems {
+			s, err := tomlValue(v.Index(idx))
+			if err != nil {
+				return "", err
+			}
+			elems[idx] = s
+		}
+		return "[" + strings.Join(elems, ", ") + "]", nil
+	case reflect.Map:
+		keys := mapKeys([]reflect.Value{v})
+		pairs := make([]string, len(keys))
+		for idx, k := range keys {
+			s, err := tomlValue(v.MapIndex(reflect.ValueOf(k)))
+			if err != nil {
+				return "", err
+			}
+			pairs[idx] = tomlString(k) + " = " + s
+		}
+		if len(pairs) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(pairs, ", ") + " }", nil
+	default:
+		return "", fmt.Errorf("%s: %w", v.Kind(), ErrTypeNotSupported)
+	}
+}
+
+// tomlString encodes s as a TOML basic string. Unlike strconv.Quote, control
+// characters are escaped as \uXXXX, and other characters are left as is.
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
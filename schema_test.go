@@ -0,0 +1,218 @@
+package cliutil_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cpliakas/cliutil"
+	"github.com/go-test/deep"
+	"github.com/spf13/cobra"
+)
+
+type SchemaInput struct {
+	DB      SchemaDB          `cliutil:"prefix=db"`
+	Name    string            `cliutil:"option=name usage='name of the app' regex=^[a-z]+$"`
+	Port    uint16            `cliutil:"option=port default=8080 min=1024 max=49151"`
+	Format  string            `cliutil:"option=format default=json oneof=json,yaml"`
+	Tags    []string          `cliutil:"option=tags default=a,b"`
+	Labels  map[string]string `cliutil:"option=labels"`
+	Token   string            `cliutil:"option=token secret required default=changeme"`
+	Target  string            `cliutil:"option=target arg=0"`
+	Verbose bool              `cliutil:"option=verbose"`
+}
+
+type SchemaDB struct {
+	Timeout time.Duration `cliutil:"option=timeout default=5s usage='query timeout'"`
+}
+
+func TestJSONSchema(t *testing.T) {
+	var buf bytes.Buffer
+	if err := cliutil.WriteJSONSchema(&buf, &SchemaInput{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var schema struct {
+		Schema     string                            `json:"$schema"`
+		Type       string                            `json:"type"`
+		Properties map[string]map[string]interface{} `json:"properties"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	if schema.Schema != cliutil.JSONSchemaVersion || schema.Type != "object" {
+		t.Errorf("got %q %q, expected object schema", schema.Schema, schema.Type)
+	}
+	if _, ok := schema.Properties["target"]; ok {
+		t.Error("expected positional arguments to be excluded")
+	}
+
+	tests := []struct {
+		option string
+		ex     map[string]interface{}
+	}{
+		{"db-timeout", map[string]interface{}{"type": "string", "pattern": `^-?([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`, "default": "5s", "description": "query timeout"}},
+		{"name", map[string]interface{}{"type": "string", "description": "name of the app", "pattern": "^[a-z]+$"}},
+		{"port", map[string]interface{}{"type": "integer", "default": 8080.0, "minimum": 1024.0, "maximum": 49151.0}},
+		{"format", map[string]interface{}{"type": "string", "default": "json", "enum": []interface{}{"json", "yaml"}}},
+		{"tags", map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "default": []interface{}{"a", "b"}}},
+		{"labels", map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "string"}}},
+		{"token", map[string]interface{}{"type": "string"}},
+		{"verbose", map[string]interface{}{"type": "boolean"}},
+	}
+	for _, tt := range tests {
+		if diff := deep.Equal(schema.Properties[tt.option], tt.ex); diff != nil {
+			t.Errorf("%s: %v", tt.option, diff)
+		}
+	}
+}
+
+func TestWriteSampleConfig(t *testing.T) {
+	type Input struct {
+		Port   int               `cliutil:"option=port default=8080 min=1 usage='port to listen on'"`
+		Ratio  float64           `cliutil:"option=ratio default=1"`
+		Tags   []string          `cliutil:"option=tags default=a,b"`
+		Labels map[string]string `cliutil:"option=labels default=env=dev"`
+		Token  string            `cliutil:"option=token secret required default=changeme"`
+	}
+
+	tests := []struct {
+		format string
+		ex     string
+	}{
+		{cliutil.OutputYAML, `# port to listen on
+# Validation: min=1
+# port: 8080
+
+# ratio: 1
+
+# tags:
+# - a
+# - b
+
+# labels:
+#   env: dev
+
+# Required.
+# token: ""
+`},
+		{cliutil.OutputTOML, `# port to listen on
+# Validation: min=1
+# port = 8080
+
+# ratio = 1.0
+
+# tags = ["a", "b"]
+
+# labels = { "env" = "dev" }
+
+# Required.
+# token = ""
+`},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := cliutil.WriteSampleConfig(&buf, &Input{}, tt.format); err != nil {
+			t.Fatal(err)
+		}
+		if actual := buf.String(); actual != tt.ex {
+			t.Errorf("%s: got:\n%s\nexpected:\n%s", tt.format, actual, tt.ex)
+		}
+	}
+
+	err := cliutil.WriteSampleConfig(&bytes.Buffer{}, &Input{}, "ini")
+	if !errors.Is(err, cliutil.ErrFormatNotSupported) {
+		t.Errorf("got %v, expected %v", err, cliutil.ErrFormatNotSupported)
+	}
+}
+
+func TestWriteSampleConfigRoundTrip(t *testing.T) {
+	type Sample struct {
+		Port   int               `cliutil:"option=port default=8080"`
+		Tags   []string          `cliutil:"option=tags default=a,b"`
+		Ports  []int             `cliutil:"option=ports default=80,443"`
+		Labels map[string]string `cliutil:"option=labels default='env=dev team=web'"`
+	}
+	type Input struct {
+		Port   int               `cliutil:"option=port"`
+		Tags   []string          `cliutil:"option=tags"`
+		Ports  []int             `cliutil:"option=ports"`
+		Labels map[string]string `cliutil:"option=labels"`
+	}
+
+	tmp, err := ioutil.TempDir(os.TempDir(), "cliutil-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	ex := Input{
+		Port:   8080,
+		Tags:   []string{"a", "b"},
+		Ports:  []int{80, 443},
+		Labels: map[string]string{"env": "dev", "team": "web"},
+	}
+
+	for _, format := range []string{cliutil.OutputYAML, cliutil.OutputTOML} {
+		var buf bytes.Buffer
+		if err := cliutil.WriteSampleConfig(&buf, &Sample{}, format); err != nil {
+			t.Fatal(err)
+		}
+
+		// Uncomment the options so the file sets the sample values.
+		var lines []string
+		for _, line := range strings.Split(buf.String(), "\n") {
+			lines = append(lines, strings.TrimPrefix(line, "# "))
+		}
+		name := filepath.Join(tmp, "config."+format)
+		writeConfig(t, name, strings.Join(lines, "\n"))
+
+		v := cliutil.InitConfig("CLIUTIL_TEST")
+		input := &Input{}
+		if err := cliutil.NewFlagger(&cobra.Command{Use: "test"}, v).SetOptions(input); err != nil {
+			t.Fatal(err)
+		}
+		if err := cliutil.LoadConfigFiles(v, name); err != nil {
+			t.Fatal(err)
+		}
+		if err := cliutil.ReadOptions(input, v); err != nil {
+			t.Fatal(err)
+		}
+		if diff := deep.Equal(*input, ex); diff != nil {
+			t.Errorf("%s: %v", format, diff)
+		}
+	}
+}
+
+func TestSchemaEscaping(t *testing.T) {
+	type Input struct {
+		Format string `cliutil:"option=format oneof='json, yaml'"`
+		Name   string "cliutil:\"option=name default=a\\\"b\x7f\""
+	}
+
+	schema, err := cliutil.JSONSchema(&Input{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	props := schema["properties"].(map[string]interface{})
+	enum := props["format"].(map[string]interface{})["enum"]
+	if diff := deep.Equal(enum, []interface{}{"json", "yaml"}); diff != nil {
+		t.Error(diff)
+	}
+
+	var buf bytes.Buffer
+	if err := cliutil.WriteSampleConfig(&buf, &Input{}, cliutil.OutputTOML); err != nil {
+		t.Fatal(err)
+	}
+	if ex := `# name = "a\"b\u007F"`; !strings.Contains(buf.String(), ex) {
+		t.Errorf("expected output to contain %q, got:\n%s", ex, buf.String())
+	}
+}
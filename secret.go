@@ -0,0 +1,184 @@
+package cliutil
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// RedactedValue replaces the value of secrets when they are rendered.
+const RedactedValue = "********"
+
+// SecretFileSuffix is appended to the name of secret options to form the
+// name of the option that reads the secret from a file, e.g., --token-file
+// and MYAPP_TOKEN_FILE for the token option.
+const SecretFileSuffix = "-file"
+
+// Redact returns a copy of v with the fields that have the secret key in their
+// cliutil tag redacted. String fields are set to RedactedValue, and fields of
+// all other types are set to their zero value. Structs are redacted in nested
+// structs, pointers, slices, arrays, maps and interfaces. v is returned as-is
+// if it doesn't contain secrets.
+func Redact(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || !hasSecrets(rv.Type(), make(map[reflect.Type]bool)) {
+		return v
+	}
+	return redactValue(rv).Interface()
+}
+
+// hasSecrets returns true if values of type t may contain secrets.
+func hasSecrets(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return hasSecrets(t.Elem(), seen)
+	case reflect.Map:
+		return hasSecrets(t.Elem(), seen)
+	case reflect.Struct:
+		for idx := 0; idx < t.NumField(); idx++ {
+			f := t.Field(idx)
+			if f.PkgPath != "" {
+				continue
+			}
+			if tagBool(parseTag(f), "secret") || hasSecrets(f.Type, seen) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func redactValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || !hasSecrets(v.Type().Elem(), make(map[reflect.Type]bool)) {
+			return v
+		}
+		n := reflect.New(v.Type().Elem())
+		n.Elem().Set(redactValue(v.Elem()))
+		return n
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		n := reflect.New(v.Type()).Elem()
+		n.Set(redactValue(v.Elem()))
+		return n
+
+	case reflect.Struct:
+		t := v.Type()
+		if !hasSecrets(t, make(map[reflect.Type]bool)) {
+			return v
+		}
+		n := reflect.New(t).Elem()
+		n.Set(v)
+		for idx := 0; idx < t.NumField(); idx++ {
+			f := t.Field(idx)
+			if f.PkgPath != "" {
+				continue
+			}
+			if tagBool(parseTag(f), "secret") {
+				n.Field(idx).Set(redacted(f.Type))
+			} else {
+				n.Field(idx).Set(redactValue(v.Field(idx)))
+			}
+		}
+		return n
+
+	case reflect.Slice:
+		if v.IsNil() || !hasSecrets(v.Type().Elem(), make(map[reflect.Type]bool)) {
+			return v
+		}
+		n := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for idx := 0; idx < v.Len(); idx++ {
+			n.Index(idx).Set(redactValue(v.Index(idx)))
+		}
+		return n
+
+	case reflect.Array:
+		if !hasSecrets(v.Type().Elem(), make(map[reflect.Type]bool)) {
+			return v
+		}
+		n := reflect.New(v.Type()).Elem()
+		for idx := 0; idx < v.Len(); idx++ {
+			n.Index(idx).Set(redactValue(v.Index(idx)))
+		}
+		return n
+
+	case reflect.Map:
+		if v.IsNil() || !hasSecrets(v.Type().Elem(), make(map[reflect.Type]bool)) {
+			return v
+		}
+		n := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			n.SetMapIndex(iter.Key(), redactValue(iter.Value()))
+		}
+		return n
+	}
+
+	return v
+}
+
+// redacted returns the redacted value for a secret field of type t.
+func redacted(t reflect.Type) reflect.Value {
+	if t.Kind() == reflect.String {
+		return reflect.ValueOf(RedactedValue).Convert(t)
+	}
+	return reflect.Zero(t)
+}
+
+// secretFileOption returns the name of the option that reads the named
+// secret option from a file.
+func secretFileOption(name string) string {
+	return name + SecretFileSuffix
+}
+
+// isOptionSet returns true if the option was set, including secret options
+// that are read from a file.
+func isOptionSet(cfg *viper.Viper, tag map[string]string) bool {
+	name := tag["option"]
+	if cfg.IsSet(name) {
+		return true
+	}
+	return tagBool(tag, "secret") && cfg.GetString(secretFileOption(name)) != ""
+}
+
+// readSecretFile reads a secret option from the file passed via the
+// <option>-file option if the option itself wasn't set. Trailing newlines are
+// trimmed from the file's contents.
+func readSecretFile(cfg *viper.Viper, name string, field reflect.Value) error {
+	path := cfg.GetString(secretFileOption(name))
+	if cfg.IsSet(name) || path == "" {
+		return nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading secret file: %w", err)
+	}
+	s := strings.TrimRight(string(b), "\r\n")
+
+	switch {
+	case field.Kind() == reflect.String:
+		field.SetString(s)
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Uint8:
+		field.SetBytes([]byte(s))
+	default:
+		return fmt.Errorf("secret file: %s: %w", field.Type(), ErrTypeNotSupported)
+	}
+
+	return nil
+}
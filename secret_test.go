@@ -0,0 +1,139 @@
+package cliutil_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cpliakas/cliutil"
+	"github.com/go-test/deep"
+	"github.com/spf13/cobra"
+)
+
+type SecretInput struct {
+	User     string `json:"user" cliutil:"option=user"`
+	Password string `json:"password" cliutil:"option=password default=changeme secret"`
+	Token    string `json:"token" cliutil:"option=token secret required oneof=valid"`
+}
+
+type SecretNested struct {
+	Inputs []*SecretInput
+	ByName map[string]SecretInput
+	Any    interface{}
+}
+
+func TestRedact(t *testing.T) {
+	input := &SecretInput{User: "me", Password: "secret", Token: "secret"}
+	nested := SecretNested{
+		Inputs: []*SecretInput{input},
+		ByName: map[string]SecretInput{"me": *input},
+		Any:    input,
+	}
+
+	redacted := &SecretInput{User: "me", Password: cliutil.RedactedValue, Token: cliutil.RedactedValue}
+	ex := SecretNested{
+		Inputs: []*SecretInput{redacted},
+		ByName: map[string]SecretInput{"me": *redacted},
+		Any:    redacted,
+	}
+
+	if diff := deep.Equal(cliutil.Redact(nested), ex); diff != nil {
+		t.Error(diff)
+	}
+
+	// The original value is not changed.
+	if input.Password != "secret" {
+		t.Errorf("got %q, expected %q", input.Password, "secret")
+	}
+
+	// Values without secrets are returned as-is.
+	data := &JsonData{Data: "test"}
+	if actual := cliutil.Redact(data); actual != data {
+		t.Errorf("got %p, expected %p", actual, data)
+	}
+}
+
+func TestFormatJSONRedacted(t *testing.T) {
+	out, err := cliutil.FormatJSONWithFilter(&SecretInput{User: "me", Password: "secret"}, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ex := `"` + cliutil.RedactedValue + `"`; out != ex {
+		t.Errorf("got %s, expected %s", out, ex)
+	}
+}
+
+func TestLogTagRedacted(t *testing.T) {
+	ctx := cliutil.ContextWithLogTag(context.Background(), "input", SecretInput{Password: "secret"})
+
+	logger := cliutil.NewLogger(cliutil.LogInfo)
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+	logger.Info(ctx, "test message")
+
+	if strings.Contains(buf.String(), "secret") {
+		t.Errorf("secret logged: %s", buf.String())
+	}
+}
+
+func TestSecretOptions(t *testing.T) {
+	cmd := &cobra.Command{
+		Use:   "test",
+		Short: "test secret options",
+		Run:   func(cmd *cobra.Command, args []string) {},
+	}
+
+	v := cliutil.InitConfig("CLIUTIL_TEST")
+	flags := cliutil.NewFlagger(cmd, v)
+
+	input := &SecretInput{}
+	if err := flags.SetOptions(input); err != nil {
+		t.Fatal(err)
+	}
+
+	// Defaults of secrets are not shown in help.
+	if usage := cmd.Flags().FlagUsages(); strings.Contains(usage, "changeme") {
+		t.Errorf("default shown in usage: %s", usage)
+	}
+
+	// Secrets are read from the file passed via the *_FILE env var.
+	tmpfile, err := ioutil.TempFile(os.TempDir(), "cliutil-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpfile.Name())
+	writeConfig(t, tmpfile.Name(), "valid\n")
+
+	os.Setenv("CLIUTIL_TEST_TOKEN_FILE", tmpfile.Name())
+	defer os.Unsetenv("CLIUTIL_TEST_TOKEN_FILE")
+
+	if err := cliutil.ReadOptions(input, v); err != nil {
+		t.Fatal(err)
+	}
+
+	ex := &SecretInput{Password: "changeme", Token: "valid"}
+	if diff := deep.Equal(input, ex); diff != nil {
+		t.Error(diff)
+	}
+
+	// Secrets are read from the file passed via the --*-file flag, and their
+	// values are not included in validation errors.
+	writeConfig(t, tmpfile.Name(), "invalid\n")
+	os.Unsetenv("CLIUTIL_TEST_TOKEN_FILE")
+	if err := cmd.ParseFlags([]string{"--token-file", tmpfile.Name()}); err != nil {
+		t.Fatal(err)
+	}
+
+	err = cliutil.ReadOptions(input, v)
+	if !errors.Is(err, cliutil.ErrInvalid) {
+		t.Fatalf("got %v, expected %v", err, cliutil.ErrInvalid)
+	}
+	exErr := "--token (CLIUTIL_TEST_TOKEN): invalid value: value of secret does not satisfy its validation rules"
+	if err.Error() != exErr {
+		t.Errorf("got %q, expected %q", err, exErr)
+	}
+}
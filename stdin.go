@@ -0,0 +1,198 @@
+package cliutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StdinSource reads data that is piped or redirected to the process via
+// STDIN. Whether data is available is detected by the file mode rather than
+// its size, which is always zero for pipes and terminals.
+type StdinSource struct {
+
+	// File is the file data is read from, defaults to os.Stdin.
+	File *os.File
+
+	// Timeout limits how long reading may take. Zero means no limit.
+	Timeout time.Duration
+}
+
+// NewStdinSource returns a StdinSource that reads os.Stdin with timeout.
+func NewStdinSource(timeout time.Duration) *StdinSource {
+	return &StdinSource{Timeout: timeout}
+}
+
+func (s *StdinSource) file() *os.File {
+	if s.File != nil {
+		return s.File
+	}
+	return os.Stdin
+}
+
+// Piped returns true if STDIN is a pipe, FIFO, socket or regular file, i.e.,
+// data was piped or redirected to the process. It returns false for terminals
+// and other character devices such as /dev/null, which would block reads or
+// never have data.
+func (s *StdinSource) Piped() (bool, error) {
+	stat, err := s.file().Stat()
+	if err != nil {
+		return false, fmt.Errorf("error getting info for stdin: %w", err)
+	}
+	return stat.Mode()&os.ModeCharDevice == 0, nil
+}
+
+// Open returns a reader for STDIN that returns an error once the timeout
+// elapses or the deadline of ctx passes, whichever is first. STDIN is read
+// directly if neither is set. STDIN is not closed when the reader is closed.
+func (s *StdinSource) Open(ctx context.Context) (io.ReadCloser, error) {
+	f := s.file()
+	if _, ok := ctx.Deadline(); !ok && s.Timeout <= 0 {
+		return ioutil.NopCloser(f), nil
+	}
+
+	ctx, cancel := withTimeoutContext(ctx, s.Timeout)
+	dr := &deadlineReader{ctx: ctx, cancel: cancel, f: f, done: make(chan struct{})}
+
+	// Pipes that support deadlines are interrupted via SetReadDeadline.
+	// Otherwise only the first read, which waits for data, is interrupted.
+	if err := f.SetReadDeadline(time.Time{}); err == nil {
+		dr.deadline = true
+		dr.wg.Add(1)
+		go dr.watch()
+	} else {
+		dr.first = true
+	}
+	return dr, nil
+}
+
+// ReadAll reads STDIN if it is piped and returns an empty string otherwise.
+func (s *StdinSource) ReadAll() (string, error) {
+	piped, err := s.Piped()
+	if err != nil || !piped {
+		return "", err
+	}
+	return s.read()
+}
+
+// read reads STDIN regardless of whether it is piped.
+func (s *StdinSource) read() (string, error) {
+	rc, err := s.Open(context.Background())
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return "", fmt.Errorf("error reading stdin: %w", err)
+	}
+	return string(b), nil
+}
+
+// opener returns an openFunc for the value of a func=stdin option. The
+// value "-" reads STDIN even if it isn't piped, an empty value reads STDIN
+// only if it is piped, and all other values are used as the data.
+func (s *StdinSource) opener(value string) openFunc {
+	return func(ctx context.Context) (io.ReadCloser, error) {
+		if value == "" {
+			piped, err := s.Piped()
+			if err != nil {
+				return nil, err
+			}
+			if !piped {
+				return ioutil.NopCloser(strings.NewReader("")), nil
+			}
+		}
+		if value == "" || value == "-" {
+			return s.Open(ctx)
+		}
+		return ioutil.NopCloser(strings.NewReader(value)), nil
+	}
+}
+
+// deadlineReader is an io.ReadCloser for STDIN whose reads return once ctx
+// is done. Files that support deadlines are interrupted by setting the read
+// deadline. Otherwise the first read waits for data in a goroutine, which is
+// left blocked until data arrives if ctx is done first, and later reads read
+// the file directly. The file is not closed.
+type deadlineReader struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	f      *os.File
+
+	done     chan struct{}
+	once     sync.Once
+	wg       sync.WaitGroup
+	deadline bool
+
+	first   bool
+	pending chan readResult
+}
+
+type readResult struct {
+	b   []byte
+	err error
+}
+
+// watch sets the read deadline of the file once ctx is done, which
+// interrupts blocked reads.
+func (dr *deadlineReader) watch() {
+	defer dr.wg.Done()
+	select {
+	case <-dr.ctx.Done():
+		dr.f.SetReadDeadline(time.Now())
+	case <-dr.done:
+	}
+}
+
+// Read implements io.Reader.Read.
+func (dr *deadlineReader) Read(p []byte) (int, error) {
+	if err := dr.ctx.Err(); err != nil {
+		return 0, fmt.Errorf("error reading stdin: %w", err)
+	}
+	if !dr.first {
+		n, err := dr.f.Read(p)
+		if errors.Is(err, os.ErrDeadlineExceeded) && dr.ctx.Err() != nil {
+			err = fmt.Errorf("error reading stdin: %w", dr.ctx.Err())
+		}
+		return n, err
+	}
+
+	if dr.pending == nil {
+		dr.pending = make(chan readResult, 1)
+		go func(ch chan<- readResult, size int) {
+			b := make([]byte, size)
+			n, err := dr.f.Read(b)
+			ch <- readResult{b[:n], err}
+		}(dr.pending, len(p))
+	}
+
+	select {
+	case res := <-dr.pending:
+		dr.first, dr.pending = false, nil
+		return copy(p, res.b), res.err
+	case <-dr.ctx.Done():
+		return 0, fmt.Errorf("error reading stdin: %w", dr.ctx.Err())
+	}
+}
+
+// Close implements io.Closer.Close. It clears the read deadline so that STDIN
+// can be read again.
+func (dr *deadlineReader) Close() error {
+	dr.once.Do(func() {
+		close(dr.done)
+		dr.cancel()
+		dr.wg.Wait()
+		if dr.deadline {
+			dr.f.SetReadDeadline(time.Time{})
+		}
+	})
+	return nil
+}
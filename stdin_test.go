@@ -0,0 +1,136 @@
+package cliutil_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cpliakas/cliutil"
+	"github.com/spf13/viper"
+)
+
+func TestStdinSourcePiped(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	go func() {
+		w.Write([]byte("testing pipe"))
+		w.Close()
+	}()
+
+	src := &cliutil.StdinSource{File: r}
+	if piped, err := src.Piped(); err != nil || !piped {
+		t.Fatalf("got %t (%v), expected true", piped, err)
+	}
+
+	s, err := src.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ex := "testing pipe"; s != ex {
+		t.Errorf("got %q, expected %q", s, ex)
+	}
+}
+
+func TestStdinSourceCharDevice(t *testing.T) {
+	f, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer f.Close()
+
+	src := &cliutil.StdinSource{File: f}
+	if piped, err := src.Piped(); err != nil || piped {
+		t.Fatalf("got %t (%v), expected false", piped, err)
+	}
+	if s, err := src.ReadAll(); err != nil || s != "" {
+		t.Errorf("got %q (%v), expected empty string", s, err)
+	}
+}
+
+func TestStdinSourceTimeout(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	src := &cliutil.StdinSource{File: r, Timeout: 50 * time.Millisecond}
+	_, err = src.ReadAll()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, expected %v", err, context.DeadlineExceeded)
+	}
+	if code := cliutil.ExitCode(err); code != cliutil.ExitCodeTimeout {
+		t.Errorf("got exit code %d, expected %d", code, cliutil.ExitCodeTimeout)
+	}
+}
+
+type StdinTimeoutInput struct {
+	Data string `cliutil:"option=data func=stdin timeout=50ms"`
+}
+
+func TestReadStdinOptionsDash(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	orig := os.Stdin
+	defer func() { os.Stdin = orig }()
+	os.Stdin = r
+
+	v := viper.New()
+	v.Set("data", "-")
+
+	// The writer is never closed, so reading times out.
+	input := &StdinTimeoutInput{}
+	err = cliutil.ReadOptions(input, v)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, expected %v", err, context.DeadlineExceeded)
+	}
+
+	v.Set("data", "testing value")
+	if err := cliutil.ReadOptions(input, v); err != nil {
+		t.Fatal(err)
+	}
+	if ex := "testing value"; input.Data != ex {
+		t.Errorf("got %q, expected %q", input.Data, ex)
+	}
+}
+
+func TestStdinSourceTimeoutReuse(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	src := &cliutil.StdinSource{File: r, Timeout: 50 * time.Millisecond}
+	if _, err := src.ReadAll(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, expected %v", err, context.DeadlineExceeded)
+	}
+
+	// The timeout doesn't affect later reads of the same file.
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("late data"))
+		w.Close()
+	}()
+
+	src.Timeout = 0
+	s, err := src.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ex := "late data"; s != ex {
+		t.Errorf("got %q, expected %q", s, ex)
+	}
+}
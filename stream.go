@@ -0,0 +1,240 @@
+package cliutil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// openFunc is a definition for functions that open the data of an option.
+type openFunc func(ctx context.Context) (io.ReadCloser, error)
+
+var readCloserType = reflect.TypeOf((*io.ReadCloser)(nil)).Elem()
+
+// readInto populates field with the data opened by open. String and []byte
+// fields are read fully, io.Reader and io.ReadCloser fields are set to a
+// reader that opens the data on the first read so that it is streamed, and
+// fields of all other types are decoded from JSON or YAML. The format is set
+// via the tag's format key and otherwise derived from the extension of name.
+func readInto(field reflect.Value, tag map[string]string, name string, open openFunc) error {
+	timeout, maxSize, err := readLimits(tag)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case field.Kind() == reflect.Interface && readCloserType.Implements(field.Type()):
+		// Streams aren't limited by the default timeout.
+		if _, ok := tag["timeout"]; !ok {
+			timeout = 0
+		}
+		field.Set(reflect.ValueOf(&lazyReader{open: open, timeout: timeout, maxSize: maxSize}))
+		return nil
+
+	case field.Kind() == reflect.String:
+		b, err := readAllWithTimeout(open, maxSize, timeout)
+		if err != nil {
+			return err
+		}
+		field.SetString(string(b))
+		return nil
+
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Uint8:
+		b, err := readAllWithTimeout(open, maxSize, timeout)
+		if err != nil {
+			return err
+		}
+		field.SetBytes(b)
+		return nil
+
+	default:
+		return decodeInto(field, dataFormat(tag, name), open, maxSize, timeout)
+	}
+}
+
+// readAllWithTimeout opens and reads all data within timeout.
+func readAllWithTimeout(open openFunc, maxSize int64, timeout time.Duration) ([]byte, error) {
+	ctx, cancel := withTimeout(timeout)
+	defer cancel()
+
+	rc, err := open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return readAllLimit(rc, maxSize)
+}
+
+// decodeInto decodes JSON or YAML data into field without buffering it.
+func decodeInto(field reflect.Value, format string, open openFunc, maxSize int64, timeout time.Duration) error {
+	ctx, cancel := withTimeout(timeout)
+	defer cancel()
+
+	rc, err := open(ctx)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	var r io.Reader = rc
+	if maxSize > 0 {
+		r = &limitedReader{r: rc, n: maxSize}
+	}
+
+	v := reflect.New(field.Type())
+	switch format {
+	case OutputJSON:
+		err = json.NewDecoder(r).Decode(v.Interface())
+	case OutputYAML:
+		err = yaml.NewDecoder(r).Decode(v.Interface())
+	default:
+		return fmt.Errorf("%s: %w", format, ErrFormatNotSupported)
+	}
+	if err != nil {
+		return fmt.Errorf("error decoding %s: %w", format, err)
+	}
+
+	field.Set(v.Elem())
+	return nil
+}
+
+// dataFormat returns the tag's format key or derives the format from the
+// extension of name, defaulting to JSON.
+func dataFormat(tag map[string]string, name string) string {
+	if format, ok := tag["format"]; ok {
+		return format
+	}
+	switch path.Ext(strings.TrimSuffix(name, ".gz")) {
+	case ".yaml", ".yml":
+		return OutputYAML
+	default:
+		return OutputJSON
+	}
+}
+
+// withTimeout returns a context that is cancelled after timeout. A timeout
+// of zero means no limit.
+func withTimeout(timeout time.Duration) (context.Context, context.CancelFunc) {
+	return withTimeoutContext(context.Background(), timeout)
+}
+
+// withTimeoutContext derives a context from ctx that is cancelled after
+// timeout. A timeout of zero means no limit.
+func withTimeoutContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// lazyReader opens its data on the first read.
+type lazyReader struct {
+	open    openFunc
+	timeout time.Duration
+	maxSize int64
+
+	r      io.Reader
+	rc     io.ReadCloser
+	cancel context.CancelFunc
+	err    error
+}
+
+// Read implements io.Reader.Read.
+func (lr *lazyReader) Read(p []byte) (int, error) {
+	if lr.r == nil && lr.err == nil {
+		var ctx context.Context
+		ctx, lr.cancel = withTimeout(lr.timeout)
+		if lr.rc, lr.err = lr.open(ctx); lr.err == nil {
+			lr.r = lr.rc
+			if lr.maxSize > 0 {
+				lr.r = &limitedReader{r: lr.rc, n: lr.maxSize}
+			}
+		}
+	}
+	if lr.err != nil {
+		return 0, lr.err
+	}
+	return lr.r.Read(p)
+}
+
+// Close implements io.Closer.Close. It is a no-op if the data wasn't opened.
+func (lr *lazyReader) Close() (err error) {
+	if lr.rc != nil {
+		err = lr.rc.Close()
+	}
+	if lr.cancel != nil {
+		lr.cancel()
+	}
+	return
+}
+
+// limitedReader returns an error wrapping ErrTooLarge once more than n bytes
+// are read.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read implements io.Reader.Read.
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, ErrTooLarge
+	}
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, ErrTooLarge
+	}
+	return n, err
+}
+
+// CloseOptions closes the readers that ReadOptions opened for the options in
+// a, e.g., io.ReadCloser fields with func=ioreader. It should be deferred
+// after calling ReadOptions or registered via EventListener.OnShutdown.
+func CloseOptions(a interface{}) error {
+	rv, rt, err := resolveStruct(a)
+	if err != nil {
+		return err
+	}
+	return closeOptions(rv, rt)
+}
+
+func closeOptions(rv reflect.Value, rt reflect.Type) (err error) {
+	for idx := 0; idx < rt.NumField(); idx++ {
+		rvf, rtf, skip := resolveField(rv, rt, idx)
+		if skip {
+			continue
+		}
+
+		tag, ok := parseOptionTag(rtf)
+		if !ok {
+			if rvf.Kind() == reflect.Struct {
+				if cerr := closeOptions(rvf, rvf.Type()); err == nil {
+					err = cerr
+				}
+			}
+			continue
+		}
+
+		if _, ok := tag["func"]; !ok || rvf.Kind() != reflect.Interface || rvf.IsNil() {
+			continue
+		}
+		if c, ok := rvf.Interface().(io.Closer); ok {
+			if cerr := c.Close(); err == nil {
+				err = cerr
+			}
+		}
+	}
+	return
+}
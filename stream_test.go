@@ -0,0 +1,130 @@
+package cliutil_test
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cpliakas/cliutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type StreamPayload struct {
+	Name  string `json:"name" yaml:"name"`
+	Count int    `json:"count" yaml:"count"`
+}
+
+type StreamInput struct {
+	Reader io.ReadCloser `cliutil:"option=reader func=ioreader"`
+	Bytes  []byte        `cliutil:"option=bytes func=ioreader"`
+	JSON   StreamPayload `cliutil:"option=json func=ioreader"`
+	YAML   StreamPayload `cliutil:"option=yaml func=ioreader"`
+	Format StreamPayload `cliutil:"option=format func=ioreader format=yaml"`
+}
+
+func TestReadStreamOptions(t *testing.T) {
+	tmp, err := ioutil.TempDir(os.TempDir(), "cliutil-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	writeConfig(t, filepath.Join(tmp, "reader.txt"), "testing reader")
+	writeConfig(t, filepath.Join(tmp, "bytes.txt"), "testing bytes")
+	writeConfig(t, filepath.Join(tmp, "data.json"), `{"name": "json", "count": 1}`)
+	writeConfig(t, filepath.Join(tmp, "data.yml"), "name: yaml\ncount: 2\n")
+	writeConfig(t, filepath.Join(tmp, "data"), "name: format\ncount: 3\n")
+
+	cmd := &cobra.Command{Use: "test", Run: func(cmd *cobra.Command, args []string) {}}
+	v := viper.New()
+	flags := cliutil.NewFlagger(cmd, v)
+
+	input := &StreamInput{}
+	if err := flags.SetOptions(input); err != nil {
+		t.Fatal(err)
+	}
+
+	v.Set("reader", filepath.Join(tmp, "reader.txt"))
+	v.Set("bytes", filepath.Join(tmp, "bytes.txt"))
+	v.Set("json", filepath.Join(tmp, "data.json"))
+	v.Set("yaml", filepath.Join(tmp, "data.yml"))
+	v.Set("format", filepath.Join(tmp, "data"))
+
+	if err := cliutil.ReadOptions(input, v); err != nil {
+		t.Fatal(err)
+	}
+	defer cliutil.CloseOptions(input)
+
+	b, err := ioutil.ReadAll(input.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, ex := string(b), "testing reader"; actual != ex {
+		t.Errorf("reader: got %q, expected %q", actual, ex)
+	}
+	if actual, ex := string(input.Bytes), "testing bytes"; actual != ex {
+		t.Errorf("bytes: got %q, expected %q", actual, ex)
+	}
+	if actual, ex := input.JSON, (StreamPayload{"json", 1}); actual != ex {
+		t.Errorf("json: got %v, expected %v", actual, ex)
+	}
+	if actual, ex := input.YAML, (StreamPayload{"yaml", 2}); actual != ex {
+		t.Errorf("yaml: got %v, expected %v", actual, ex)
+	}
+	if actual, ex := input.Format, (StreamPayload{"format", 3}); actual != ex {
+		t.Errorf("format: got %v, expected %v", actual, ex)
+	}
+
+	if err := cliutil.CloseOptions(input); err != nil {
+		t.Errorf("unexpected error closing options: %v", err)
+	}
+}
+
+type LazyStreamInput struct {
+	Reader io.Reader `cliutil:"option=reader func=ioreader maxsize=4"`
+}
+
+func TestReadStreamOptionsLazy(t *testing.T) {
+	v := viper.New()
+	v.Set("reader", filepath.Join(os.TempDir(), "cliutil-does-not-exist"))
+
+	// Reading the options succeeds because the stream isn't opened yet.
+	input := &LazyStreamInput{}
+	if err := cliutil.ReadOptions(input, v); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ioutil.ReadAll(input.Reader); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("got %v, expected %v", err, os.ErrNotExist)
+	}
+
+	v.Set("reader", "data:,testing")
+	if err := cliutil.ReadOptions(input, v); err != nil {
+		t.Fatal(err)
+	}
+	defer cliutil.CloseOptions(input)
+
+	if _, err := ioutil.ReadAll(input.Reader); !errors.Is(err, cliutil.ErrTooLarge) {
+		t.Errorf("got %v, expected %v", err, cliutil.ErrTooLarge)
+	}
+}
+
+type StdinStreamInput struct {
+	Data StreamPayload `cliutil:"option=data func=stdin"`
+}
+
+func TestReadStdinStreamOptions(t *testing.T) {
+	v := viper.New()
+	v.Set("data", `{"name": "stdin", "count": 4}`)
+
+	input := &StdinStreamInput{}
+	if err := cliutil.ReadOptions(input, v); err != nil {
+		t.Fatal(err)
+	}
+	if actual, ex := input.Data, (StreamPayload{"stdin", 4}); actual != ex {
+		t.Errorf("got %v, expected %v", actual, ex)
+	}
+}
@@ -0,0 +1,222 @@
+package cliutil
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Err* variables contain errors returned when opening URIs.
+var (
+	ErrSchemeNotSupported = errors.New("scheme not supported")
+	ErrTooLarge           = errors.New("data exceeds the maximum size")
+)
+
+// DefaultURITimeout is the default time allowed to open and read a URI.
+const DefaultURITimeout = 30 * time.Second
+
+// SchemeHandler is a definition for functions that open the resource
+// identified by u for reading. Handlers should honor ctx so that timeouts are
+// enforced.
+type SchemeHandler func(ctx context.Context, u *url.URL) (io.ReadCloser, error)
+
+var schemes map[string]SchemeHandler
+
+// RegisterScheme registers a SchemeHandler that opens URIs with the scheme,
+// e.g., "s3". Registering a scheme replaces the existing handler.
+func RegisterScheme(scheme string, fn SchemeHandler) { schemes[strings.ToLower(scheme)] = fn }
+
+func init() {
+	schemes = map[string]SchemeHandler{
+		"":      openFile,
+		"file":  openFile,
+		"http":  openHTTP,
+		"https": openHTTP,
+		"env":   openEnv,
+		"data":  openData,
+		"stdin": openStdin,
+	}
+}
+
+// OpenURI opens the resource identified by uri using the SchemeHandler
+// registered to its scheme. Paths without a scheme are opened as files, and
+// "-" reads STDIN. Gzip compressed data is decompressed automatically. The
+// returned io.ReadCloser must be closed. Closing it doesn't cancel ctx, which
+// remains owned by the caller and aborts reads once it is done.
+func OpenURI(ctx context.Context, uri string) (io.ReadCloser, error) {
+	if uri == "-" {
+		uri = "stdin://-"
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing uri: %w", err)
+	}
+
+	var fn SchemeHandler
+	switch scheme := strings.ToLower(u.Scheme); {
+	case len(scheme) == 1:
+		// Windows paths with drive letters, e.g., c:\path\to\file.
+		fn = func(context.Context, *url.URL) (io.ReadCloser, error) { return os.Open(uri) }
+	default:
+		var ok bool
+		if fn, ok = schemes[scheme]; !ok {
+			return nil, fmt.Errorf("%s: %w", u.Scheme, ErrSchemeNotSupported)
+		}
+	}
+
+	rc, err := fn(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	return decompress(rc)
+}
+
+// decompress wraps rc with a gzip reader if the data is gzip compressed.
+func decompress(rc io.ReadCloser) (io.ReadCloser, error) {
+	br := bufio.NewReader(rc)
+	magic, _ := br.Peek(2)
+	if !bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		return readCloser{br, rc.Close}, nil
+	}
+
+	gz, err := gzip.NewReader(br)
+	if err != nil {
+		rc.Close()
+		return nil, fmt.Errorf("error decompressing data: %w", err)
+	}
+	return readCloser{gz, func() error {
+		gz.Close()
+		return rc.Close()
+	}}, nil
+}
+
+// readCloser combines an io.Reader with a close function.
+type readCloser struct {
+	io.Reader
+	close func() error
+}
+
+// Close implements io.Closer.Close.
+func (rc readCloser) Close() error { return rc.close() }
+
+// ReadURI reads the resource identified by uri. An error wrapping ErrTooLarge
+// is returned if the data exceeds maxSize bytes, and the read is aborted if it
+// takes longer than timeout. A maxSize or timeout of zero means no limit.
+func ReadURI(uri string, maxSize int64, timeout time.Duration) ([]byte, error) {
+	return readAllWithTimeout(func(ctx context.Context) (io.ReadCloser, error) {
+		return OpenURI(ctx, uri)
+	}, maxSize, timeout)
+}
+
+// readAllLimit reads all data from r, returning an error if it exceeds
+// maxSize bytes. A maxSize of zero means no limit.
+func readAllLimit(r io.Reader, maxSize int64) ([]byte, error) {
+	if maxSize > 0 {
+		r = io.LimitReader(r, maxSize+1)
+	}
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading data: %w", err)
+	}
+	if maxSize > 0 && int64(len(b)) > maxSize {
+		return nil, fmt.Errorf("%w of %v bytes", ErrTooLarge, maxSize)
+	}
+
+	return b, nil
+}
+
+func openFile(_ context.Context, u *url.URL) (io.ReadCloser, error) {
+	return os.Open(filepath.FromSlash(u.Host + u.Path))
+}
+
+// openHTTP opens HTTP(S) URIs and returns an error for non-2xx responses.
+func openHTTP(ctx context.Context, u *url.URL) (io.ReadCloser, error) {
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error requesting uri: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("error requesting uri: unexpected status %s", resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
+// openEnv reads the environment variable named by the host, e.g., env://NAME.
+func openEnv(_ context.Context, u *url.URL) (io.ReadCloser, error) {
+	name := u.Host + strings.TrimPrefix(u.Path, "/")
+	s, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, fmt.Errorf("environment variable %s not set", name)
+	}
+	return ioutil.NopCloser(strings.NewReader(s)), nil
+}
+
+// openData reads data URIs as defined by RFC 2397, e.g.,
+// data:text/plain;base64,SGVsbG8=.
+func openData(_ context.Context, u *url.URL) (io.ReadCloser, error) {
+	s := u.Opaque
+	if s == "" {
+		s = strings.TrimPrefix(u.String(), u.Scheme+":")
+	}
+
+	idx := strings.Index(s, ",")
+	if idx < 0 {
+		return nil, errors.New("error parsing data uri: missing comma")
+	}
+	meta, data := s[:idx], s[idx+1:]
+
+	var b []byte
+	var err error
+	if strings.HasSuffix(meta, ";base64") {
+		b, err = base64.StdEncoding.DecodeString(data)
+	} else {
+		var unescaped string
+		unescaped, err = url.PathUnescape(data)
+		b = []byte(unescaped)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing data uri: %w", err)
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(b)), nil
+}
+
+// openStdin reads STDIN, e.g., stdin://-, until ctx is done. STDIN is not
+// closed.
+func openStdin(ctx context.Context, _ *url.URL) (io.ReadCloser, error) {
+	return NewStdinSource(0).Open(ctx)
+}
+
+// DirSchemeHandler returns a SchemeHandler that opens files in root, where
+// the host and path of the URI are relative to root. It is useful as a local
+// stand-in for remote stores, e.g., registering it to the "s3" scheme opens
+// s3://bucket/key as <root>/bucket/key.
+func DirSchemeHandler(root string) SchemeHandler {
+	return func(_ context.Context, u *url.URL) (io.ReadCloser, error) {
+		rel := filepath.FromSlash(filepath.Clean("/" + u.Host + u.Path))
+		return os.Open(filepath.Join(root, rel))
+	}
+}
@@ -0,0 +1,125 @@
+package cliutil_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cpliakas/cliutil"
+)
+
+func gzipData(t *testing.T, s string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadURI(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.Write([]byte("testing http"))
+		case "/gzip":
+			w.Write(gzipData(t, "testing gzip"))
+		case "/slow":
+			time.Sleep(500 * time.Millisecond)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	tmp, err := ioutil.TempDir(os.TempDir(), "cliutil-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := os.MkdirAll(filepath.Join(tmp, "bucket"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	writeConfig(t, filepath.Join(tmp, "bucket", "key"), "testing s3")
+	cliutil.RegisterScheme("s3", cliutil.DirSchemeHandler(tmp))
+
+	os.Setenv("CLIUTIL_TEST_URI", "testing env")
+	defer os.Unsetenv("CLIUTIL_TEST_URI")
+
+	tests := []struct {
+		uri     string
+		maxSize int64
+		timeout time.Duration
+		ex      string
+		exErr   bool
+	}{
+		{ts.URL + "/ok", 0, 0, "testing http", false},
+		{ts.URL + "/gzip", 0, 0, "testing gzip", false},
+		{ts.URL + "/missing", 0, 0, "", true},
+		{ts.URL + "/slow", 0, 100 * time.Millisecond, "", true},
+		{ts.URL + "/ok", 7, 0, "", true},
+		{"env://CLIUTIL_TEST_URI", 0, 0, "testing env", false},
+		{"env://CLIUTIL_TEST_MISSING", 0, 0, "", true},
+		{"data:,testing%20data", 0, 0, "testing data", false},
+		{"data:text/plain;base64,dGVzdGluZyBiYXNlNjQ=", 0, 0, "testing base64", false},
+		{"s3://bucket/key", 0, 0, "testing s3", false},
+		{"s3://bucket/../../etc/passwd", 0, 0, "", true},
+		{"unknown://resource", 0, 0, "", true},
+	}
+
+	for _, tt := range tests {
+		b, err := cliutil.ReadURI(tt.uri, tt.maxSize, tt.timeout)
+		if (err != nil) != tt.exErr {
+			t.Errorf("%s: got error %v, expected error %t", tt.uri, err, tt.exErr)
+		}
+		if actual := string(b); actual != tt.ex {
+			t.Errorf("%s: got %q, expected %q", tt.uri, actual, tt.ex)
+		}
+	}
+}
+
+func TestReadURITooLarge(t *testing.T) {
+	_, err := cliutil.ReadURI("data:,testing", 4, 0)
+	if !errors.Is(err, cliutil.ErrTooLarge) {
+		t.Errorf("got %v, expected %v", err, cliutil.ErrTooLarge)
+	}
+}
+
+func TestReadURIGzipFile(t *testing.T) {
+	tmpfile, err := ioutil.TempFile(os.TempDir(), "cliutil-*.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpfile.Name())
+
+	if _, err := tmpfile.Write(gzipData(t, "testing gzip file")); err != nil {
+		t.Fatal(err)
+	}
+	tmpfile.Close()
+
+	b, err := cliutil.ReadURI(tmpfile.Name(), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := string(b); actual != "testing gzip file" {
+		t.Errorf("got %q, expected %q", actual, "testing gzip file")
+	}
+}
+
+func TestReadURISchemeNotSupported(t *testing.T) {
+	_, err := cliutil.ReadURI("unknown://resource", 0, 0)
+	if !errors.Is(err, cliutil.ErrSchemeNotSupported) {
+		t.Errorf("got %v, expected %v", err, cliutil.ErrSchemeNotSupported)
+	}
+}
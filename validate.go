@@ -1,6 +1,13 @@
 package cliutil
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 	"unicode"
 )
 
@@ -42,3 +49,232 @@ func HasSpace(s string) bool {
 	}
 	return false
 }
+
+// ValidatorFunc is a definition for functions that validate option values.
+// An error describing why the value is not valid is returned.
+type ValidatorFunc func(v interface{}) error
+
+var validators map[string]ValidatorFunc
+
+// RegisterValidatorFunc registers a ValidatorFunc by name so that it can be
+// referenced via the validate key of the cliutil tag, e.g., validate=name.
+func RegisterValidatorFunc(name string, fn ValidatorFunc) { validators[name] = fn }
+
+func init() {
+	validators = map[string]ValidatorFunc{
+		"letters": func(v interface{}) error {
+			if !IsLetters(fmt.Sprint(v)) {
+				return errors.New("must only contain letters")
+			}
+			return nil
+		},
+		"number": func(v interface{}) error {
+			if !IsNumber(fmt.Sprint(v)) {
+				return errors.New("must only contain numbers")
+			}
+			return nil
+		},
+		"nospace": func(v interface{}) error {
+			if HasSpace(fmt.Sprint(v)) {
+				return errors.New("must not contain spaces")
+			}
+			return nil
+		},
+	}
+}
+
+// validationRule checks field against the rule's parameter.
+type validationRule func(field reflect.Value, param string) error
+
+// validationRules are the tag keys that validate options in the order they
+// are evaluated.
+var validationRules = []struct {
+	key string
+	fn  validationRule
+}{
+	{"len", validateLen},
+	{"min", validateMin},
+	{"max", validateMax},
+	{"oneof", validateOneOf},
+	{"enum", validateOneOf},
+	{"regex", validateRegex},
+	{"validate", validateFuncs},
+}
+
+// validateOption evaluates the validation rules in tag against field and
+// returns the first failure. Failures wrap ErrInvalid, whereas other errors
+// indicate that the rules themselves are not valid.
+func validateOption(tag map[string]string, field reflect.Value) error {
+	for _, rule := range validationRules {
+		param, ok := tag[rule.key]
+		if !ok {
+			continue
+		}
+		if err := rule.fn(field, param); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasLen returns true if the length of field can be measured.
+func hasLen(field reflect.Value) bool {
+	switch field.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return true
+	}
+	return false
+}
+
+func validateLen(field reflect.Value, param string) error {
+	if !hasLen(field) {
+		return fmt.Errorf("len: %s: %w", field.Type(), ErrTypeNotSupported)
+	}
+	n, err := strconv.Atoi(param)
+	if err != nil {
+		return fmt.Errorf("len: %w", err)
+	}
+	if field.Len() != n {
+		return fmt.Errorf("%w: length must be %v", ErrInvalid, n)
+	}
+	return nil
+}
+
+func validateMin(field reflect.Value, param string) error {
+	cmp, err := compare(field, param)
+	if err != nil {
+		return fmt.Errorf("min: %w", err)
+	}
+	if cmp < 0 {
+		if hasLen(field) {
+			return fmt.Errorf("%w: length must be at least %s", ErrInvalid, param)
+		}
+		return fmt.Errorf("%w: must be at least %s", ErrInvalid, param)
+	}
+	return nil
+}
+
+func validateMax(field reflect.Value, param string) error {
+	cmp, err := compare(field, param)
+	if err != nil {
+		return fmt.Errorf("max: %w", err)
+	}
+	if cmp > 0 {
+		if hasLen(field) {
+			return fmt.Errorf("%w: length must be at most %s", ErrInvalid, param)
+		}
+		return fmt.Errorf("%w: must be at most %s", ErrInvalid, param)
+	}
+	return nil
+}
+
+// compare returns -1, 0 or 1 depending on whether field is less than, equal
+// to or greater than param. Durations are compared to durations, numbers are
+// compared by value, and strings, slices and maps are compared by length.
+func compare(field reflect.Value, param string) (int, error) {
+	var v, p float64
+	var err error
+
+	switch {
+	case field.Type() == reflect.TypeOf(time.Duration(0)):
+		var d time.Duration
+		if d, err = time.ParseDuration(param); err != nil {
+			return 0, err
+		}
+		v, p = float64(field.Int()), float64(d)
+	case hasLen(field):
+		var n int
+		if n, err = strconv.Atoi(param); err != nil {
+			return 0, err
+		}
+		v, p = float64(field.Len()), float64(n)
+	default:
+		switch field.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			v = float64(field.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			v = float64(field.Uint())
+		case reflect.Float32, reflect.Float64:
+			v = field.Float()
+		default:
+			return 0, fmt.Errorf("%s: %w", field.Type(), ErrTypeNotSupported)
+		}
+		if p, err = strconv.ParseFloat(param, 64); err != nil {
+			return 0, err
+		}
+	}
+
+	switch {
+	case v < p:
+		return -1, nil
+	case v > p:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// elements returns the string representation of field, or of each element if
+// field is a slice, so rules can be applied to every value passed.
+func elements(field reflect.Value) []string {
+	if field.Kind() != reflect.Slice && field.Kind() != reflect.Array {
+		return []string{fmt.Sprint(field.Interface())}
+	}
+	s := make([]string, field.Len())
+	for idx := range s {
+		s[idx] = fmt.Sprint(field.Index(idx).Interface())
+	}
+	return s
+}
+
+func validateOneOf(field reflect.Value, param string) error {
+	allowed, err := ParseStringSlice(param)
+	if err != nil {
+		return fmt.Errorf("oneof: %w", err)
+	}
+	for _, elem := range elements(field) {
+		if !contains(allowed, elem) {
+			return fmt.Errorf("%w: %q must be one of %s", ErrInvalid, elem, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
+func contains(s []string, v string) bool {
+	for _, elem := range s {
+		if elem == v {
+			return true
+		}
+	}
+	return false
+}
+
+func validateRegex(field reflect.Value, param string) error {
+	re, err := regexp.Compile(param)
+	if err != nil {
+		return fmt.Errorf("regex: %w", err)
+	}
+	for _, elem := range elements(field) {
+		if !re.MatchString(elem) {
+			return fmt.Errorf("%w: %q must match %s", ErrInvalid, elem, param)
+		}
+	}
+	return nil
+}
+
+func validateFuncs(field reflect.Value, param string) error {
+	names, err := ParseStringSlice(param)
+	if err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+	for _, name := range names {
+		fn, ok := validators[name]
+		if !ok {
+			return fmt.Errorf("%s: %w", name, ErrValidatorNotRegistered)
+		}
+		if err := fn(field.Interface()); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+	}
+	return nil
+}
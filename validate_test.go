@@ -1,9 +1,12 @@
 package cliutil_test
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/cpliakas/cliutil"
+	"github.com/spf13/viper"
 )
 
 func TestIsLetters(t *testing.T) {
@@ -56,3 +59,91 @@ func TestHasSpace(t *testing.T) {
 		}
 	}
 }
+
+type ValidateInput struct {
+	Port    uint16        `cliutil:"option=port min=1024 max=49151"`
+	Format  string        `cliutil:"option=format oneof=json,yaml"`
+	Tags    []string      `cliutil:"option=tags enum=a,b"`
+	Code    string        `cliutil:"option=code len=3 regex='^[A-Z]+$'"`
+	Name    string        `cliutil:"option=name min=2 validate=letters"`
+	Timeout time.Duration `cliutil:"option=timeout max=1m"`
+	Even    int           `cliutil:"option=even validate=even"`
+	Unset   int           `cliutil:"option=unset min=1"`
+}
+
+func TestReadOptionsValidate(t *testing.T) {
+	cliutil.RegisterValidatorFunc("even", func(v interface{}) error {
+		if v.(int)%2 != 0 {
+			return errors.New("must be even")
+		}
+		return nil
+	})
+
+	tests := []struct {
+		name  string
+		value interface{}
+		valid bool
+	}{
+		{"port", 8080, true},
+		{"port", 80, false},
+		{"port", 50000, false},
+		{"format", "yaml", true},
+		{"format", "xml", false},
+		{"tags", "a,b", true},
+		{"tags", "a,c", false},
+		{"code", "ABC", true},
+		{"code", "AB", false},
+		{"code", "abc", false},
+		{"name", "ab", true},
+		{"name", "a", false},
+		{"name", "a1", false},
+		{"timeout", "30s", true},
+		{"timeout", "2m", false},
+		{"even", 2, true},
+		{"even", 3, false},
+	}
+
+	for _, tt := range tests {
+		v := viper.New()
+		v.Set(tt.name, tt.value)
+
+		err := cliutil.ReadOptions(&ValidateInput{}, v)
+		if tt.valid && err != nil {
+			t.Errorf("%s=%v: got %v, expected nil", tt.name, tt.value, err)
+		}
+		if !tt.valid && !errors.Is(err, cliutil.ErrInvalid) {
+			t.Errorf("%s=%v: got %v, expected %v", tt.name, tt.value, err, cliutil.ErrInvalid)
+		}
+	}
+}
+
+func TestReadOptionsValidateAggregate(t *testing.T) {
+	v := viper.New()
+	v.Set("port", 80)
+	v.Set("format", "xml")
+
+	var errs cliutil.OptionErrors
+	err := cliutil.ReadOptions(&ValidateInput{}, v)
+	if !errors.As(err, &errs) {
+		t.Fatalf("got %T, expected cliutil.OptionErrors", err)
+	}
+
+	ex := `--port: invalid value: must be at least 1024; --format: invalid value: "xml" must be one of json, yaml`
+	if actual := errs.Error(); actual != ex {
+		t.Errorf("got %q, expected %q", actual, ex)
+	}
+}
+
+type UnregisteredValidatorInput struct {
+	Value string `cliutil:"option=value validate=unregistered"`
+}
+
+func TestReadOptionsValidatorNotRegistered(t *testing.T) {
+	v := viper.New()
+	v.Set("value", "test")
+
+	err := cliutil.ReadOptions(&UnregisteredValidatorInput{}, v)
+	if !errors.Is(err, cliutil.ErrValidatorNotRegistered) {
+		t.Errorf("got %v, expected %v", err, cliutil.ErrValidatorNotRegistered)
+	}
+}